@@ -2,12 +2,9 @@ package main
 
 import (
 	"api/internal/app"
+	"os"
 )
 
 func main() {
-	fiber := app.NewFiberApp()
-	
-	app.ConnectRoutes(fiber)
-
-	fiber.Listen(":8080")
+	os.Exit(app.Run(os.Args[1:]))
 }
@@ -1,64 +1,342 @@
 package app
 
 import (
+	"api/internal/config"
 	"api/internal/controllers"
 	"api/internal/database"
+	"api/internal/logging"
+	"api/internal/metrics"
 	"api/internal/middlewares"
+	"api/internal/openapi"
 	"api/internal/repositories"
 	"api/internal/services"
+	"api/internal/services/health"
+	"api/internal/tracing"
+	"api/internal/workers"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-func NewFiberApp() *fiber.App {
-	app := fiber.New(fiber.Config{})
+// readinessCheckTimeout bounds each check of /readyz.
+const readinessCheckTimeout = 2 * time.Second
 
-	app.Use(logger.New())
+// Run starts the pool, the workers and the server and blocks until the
+// application is shut down, or runs `migrate <command>` or `openapi`. It returns the exit
+// code of the process.
+func Run(args []string) int {
+	if len(args) > 0 && args[0] == "migrate" {
+		return Migrate(args[1:])
+	}
+
+	if len(args) > 0 && args[0] == "openapi" {
+		return OpenApi(args[1:])
+	}
+
+	cfg, code, ok := loadConfig("app", args)
+	if !ok {
+		return code
+	}
+
+	logger := logging.New(cfg.Log, os.Stderr)
+	// Whatever still logs through log or slog directly ends up here as well.
+	slog.SetDefault(logger)
+
+	lifecycle := NewLifecycle(cfg.Server.ShutdownTimeout, logger)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		logger.Error("Failed to start", "error", err)
+		return ExitFailed
+	}
+
+	// Appended first to stop last, once the spans of every request are ended.
+	lifecycle.Append(Hook{Name: "tracing", Stop: shutdownTracing})
+
+	db, err := database.NewPostgresDatabase(cfg.Database).Connect(cfg.Database.Dsn.Reveal())
+	if err != nil {
+		logger.Error("Failed to start", "error", err)
+		return ExitFailed
+	}
+
+	metrics.Registry.MustRegister(metrics.NewPoolCollector(db))
+
+	// Versions for /readyz are read through the pool.
+	versions, err := database.NewPoolMigrator(db, cfg.Database.MigrationsTable)
+	if err != nil {
+		logger.Error("Failed to start", "error", err)
+		return ExitFailed
+	}
+
+	lifecycle.Append(Hook{
+		Name: "db",
+		Start: func(ctx context.Context) error {
+			return database.Wait(ctx, db, cfg.Database.ConnectTimeout, logger)
+		},
+		Stop: func(ctx context.Context) error {
+			versions.Close()
+
+			// Close waits for every acquired connection, so a stuck query must
+			// not hold the process forever.
+			closed := make(chan struct{})
+			go func() {
+				db.Close()
+				close(closed)
+			}()
+
+			select {
+			case <-closed:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+
+	if cfg.Database.MigrateOnStart {
+		lifecycle.Append(Hook{
+			Name: "migrations",
+			Start: func(ctx context.Context) error {
+				return migrateUp(ctx, cfg, logger)
+			},
+		})
+	}
+
+	idempotencyRepository := repositories.NewIdempotencyRepository(db)
+	purge := workers.NewPeriodic("idempotency-purge", cfg.Idempotency.PurgeInterval,
+		func(ctx context.Context) error {
+			_, err := idempotencyRepository.Purge(ctx)
+			return err
+		}, logger)
+	lifecycle.Append(Hook{Name: purge.Name(), Start: purge.Start, Stop: purge.Stop})
+
+	checker := health.NewChecker(readinessCheckTimeout)
+	checker.Add("db", db.Ping)
+	checker.Add("migrations", func(ctx context.Context) error {
+		current, target, err := versions.Versions(ctx)
+		if err != nil {
+			return err
+		}
+		if current != target {
+			return fmt.Errorf("db is at version %d, expected %d", current, target)
+		}
+		return nil
+	})
+	checker.Add(purge.Name(), func(context.Context) error {
+		if !purge.Running() {
+			return errors.New("worker is not running")
+		}
+		return nil
+	})
+
+	fiber := NewFiberApp(cfg, logger)
+	ConnectRoutes(fiber, db, cfg, checker, logger)
+
+	// The admin server stops after the main one, so the drain can be watched.
+	if cfg.Metrics.Addr != "" {
+		lifecycle.Append(serve(lifecycle, "metrics", NewMetricsApp(cfg, logger), cfg.Metrics.Addr))
+	}
+
+	lifecycle.Append(serve(lifecycle, "http", fiber, cfg.Server.Addr))
+
+	return lifecycle.Run()
+}
+
+// serve makes a hook running app on addr.
+func serve(lifecycle *Lifecycle, name string, app *fiber.App, addr string) Hook {
+	return Hook{
+		Name: name,
+		Start: func(context.Context) error {
+			// Listening here rather than in the goroutine makes a busy port a
+			// start failure.
+			listener, err := net.Listen("tcp", addr)
+			if err != nil {
+				return err
+			}
+
+			go func() {
+				if err := app.Listener(listener); err != nil {
+					lifecycle.Fail(name, err)
+				}
+			}()
+
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			err := app.ShutdownWithContext(ctx)
+			if errors.Is(err, context.DeadlineExceeded) {
+				lifecycle.logger.Warn("Requests still running after the shutdown timeout were dropped", "server", name)
+			}
+			return err
+		},
+	}
+}
+
+// NewMetricsApp serves /metrics alone, for an admin port that is not exposed
+// with the API.
+func NewMetricsApp(cfg *config.Config, logger *slog.Logger) *fiber.App {
+	app := fiber.New(fiber.Config{
+		ErrorHandler:          middlewares.NewErrorHandler(logger),
+		DisableStartupMessage: true,
+	})
+
+	app.Get("/metrics", metricsHandlers(cfg)...)
+
+	return app
+}
+
+// metricsHandlers guard /metrics with its own token, when there is one.
+func metricsHandlers(cfg *config.Config) []fiber.Handler {
+	handler := adaptor.HTTPHandler(promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}))
+
+	if cfg.Metrics.Token == "" {
+		return []fiber.Handler{handler}
+	}
+
+	return []fiber.Handler{middlewares.New(cfg.Metrics.Token.Reveal()), handler}
+}
+
+func NewFiberApp(cfg *config.Config, logger *slog.Logger) *fiber.App {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: middlewares.NewErrorHandler(logger),
+	})
+
+	// Probes hit these every few seconds and would drown the log and traces.
+	probe := func(c *fiber.Ctx) bool {
+		return c.Path() == "/healthz" || c.Path() == "/readyz"
+	}
+
+	app.Use(middlewares.NewRequestId())
+	app.Use(middlewares.NewLogger(logger, probe))
+	app.Use(middlewares.NewTracing(probe))
+	app.Use(middlewares.NewMetrics())
 	app.Use(recover.New())
+	app.Use(middlewares.NewContentNegotiation())
+
+	if len(cfg.Cors.AllowOrigins) > 0 {
+		app.Use(cors.New(cors.Config{AllowOrigins: strings.Join(cfg.Cors.AllowOrigins, ",")}))
+	}
 
 	return app
 }
 
-func ConnectRoutes(app *fiber.App) {
-	configService := services.NewConfigService()
+func ConnectRoutes(app *fiber.App, db *pgxpool.Pool, cfg *config.Config, checker *health.Checker, logger *slog.Logger) {
+	// Probes come without the token, so these stay outside of /api.
+	healthController := controllers.NewHealthController(checker)
+	app.Get("/healthz", healthController.Live)
+	app.Get("/readyz", healthController.Ready)
 
-	db := ConnectToDb(configService)
-	if db == nil {
-		panic("Db is not connected")
+	// Without an admin port /metrics is served here, but only with a token.
+	if cfg.Metrics.Addr == "" && cfg.Metrics.Token != "" {
+		app.Get("/metrics", metricsHandlers(cfg)...)
 	}
 
-	api := app.Group("/api").Use(middlewares.New(configService))
+	// Registered before the token of /api, so the docs can be read without it.
+	app.Get("/api/openapi.json", openapi.Handler(openApiInfo, endpoints))
+	app.Get("/api/docs", openapi.Ui)
+	app.Get("/api/docs/:file", openapi.Assets)
+
+	api := app.Group("/api").Use(middlewares.New(cfg.Auth.Token.Reveal()))
+
+	idempotencyRepository := repositories.NewIdempotencyRepository(db)
+	idempotency := middlewares.NewIdempotency(idempotencyRepository, cfg.Idempotency.KeysTTL, logger)
+
+	deadline := middlewares.NewDeadline(cfg.Timeouts.Request)
+	suggestDeadline := middlewares.NewDeadline(cfg.Timeouts.Suggest)
+	importDeadline := middlewares.NewDeadline(cfg.Timeouts.Import)
+
+	unitOfWork := repositories.NewUnitOfWork(db, logger)
 
 	users := api.Group("/users")
-	userRepository := repositories.NewUserRepository(db)
-	userService := services.NewUserService(userRepository)
+	userRepository := repositories.NewUserRepository(db, logger)
+	userService := services.NewUserService(unitOfWork, userRepository, logger)
 	userController := controllers.NewUserController(userService)
 
-	users.Get("/", userController.Find)
-	users.Post("/", userController.Create)
-	users.Put("/", userController.Update)
-	users.Delete("/", userController.Delete)
+	users.Get("/", deadline, userController.Find)
+	users.Get("/suggest", suggestDeadline, userController.Suggest)
+	users.Post("/", deadline, idempotency, userController.Create)
+	users.Get("/by-social/:socialId", deadline, userController.FindOne)
+	users.Put("/by-social/:socialId", deadline, userController.Update)
+	users.Patch("/by-social/:socialId", deadline, userController.Patch)
+	users.Delete("/by-social/:socialId", deadline, userController.Delete)
+	users.Get("/:id<int>", deadline, userController.FindOne)
+	users.Put("/:id<int>", deadline, userController.Update)
+	users.Patch("/:id<int>", deadline, userController.Patch)
+	users.Delete("/:id<int>", deadline, userController.Delete)
 
 	pastes := api.Group("/pastes")
 	pasteRepository := repositories.NewPasteRepository(db)
-	pasteService := services.NewPasteService(pasteRepository)
-	pasteController := controllers.NewPasteController(pasteService)
-
-	pastes.Get("/", pasteController.FindPaste)
-	pastes.Get("/search", pasteController.SearchPaste)
-	pastes.Post("/", pasteController.CreatePaste)
-	pastes.Put("/", pasteController.UpdatePaste)
-	pastes.Delete("/", pasteController.DeletePaste)
+	pasteService := services.NewPasteService(unitOfWork, pasteRepository, userRepository, logger)
+	pasteController := controllers.NewPasteController(pasteService, logger)
+
+	pastes.Get("/", deadline, pasteController.FindPaste)
+	pastes.Get("/search", deadline, pasteController.SearchPaste)
+	pastes.Get("/suggest", suggestDeadline, pasteController.SuggestPaste)
+	pastes.Get("/export", pasteController.ExportPastes)
+	pastes.Post("/", deadline, idempotency, pasteController.CreatePaste)
+	pastes.Post("/batch", deadline, idempotency, pasteController.BatchPastes)
+	pastes.Post("/import", importDeadline, idempotency, pasteController.ImportPastes)
+	pastes.Get("/:id<int>", deadline, pasteController.FindOnePaste)
+	pastes.Get("/:id<int>/raw", deadline, pasteController.RawPaste)
+	pastes.Put("/:id<int>", deadline, pasteController.UpdatePaste)
+	pastes.Patch("/:id<int>", deadline, pasteController.PatchPaste)
+	pastes.Delete("/:id<int>", deadline, pasteController.DeletePaste)
+
+	savedSearches := api.Group("/saved-searches")
+	savedSearchRepository := repositories.NewSavedSearchRepository(db)
+	savedSearchService := services.NewSavedSearchService(savedSearchRepository, pasteService, logger)
+	savedSearchController := controllers.NewSavedSearchController(savedSearchService)
+
+	savedSearches.Get("/", deadline, savedSearchController.Find)
+	savedSearches.Get("/execute", deadline, savedSearchController.Execute)
+	savedSearches.Post("/", deadline, idempotency, savedSearchController.Create)
+	savedSearches.Put("/:id<int>", deadline, savedSearchController.Update)
+	savedSearches.Delete("/:id<int>", deadline, savedSearchController.Delete)
 }
 
-func ConnectToDb(configService services.ConfigService) *pgxpool.Pool {
-	dbUrl, err := configService.Get("GOOSE_DBSTRING")
+// loadConfig parses the flags and loads the config. When ok is false the
+// process should exit with code right away: on -h, --print-config or an
+// invalid config.
+func loadConfig(name string, args []string) (cfg *config.Config, code int, ok bool) {
+	flags, err := config.ParseFlags(name, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil, ExitOK, false
+	}
 	if err != nil {
-		panic("GOOSE_DBSTRING is not provided in .env")
+		return nil, ExitFailed, false
 	}
-	db := database.NewPostgresDatabase()
-	return db.Connect(dbUrl)
+
+	cfg, err = config.Load(flags)
+
+	if flags.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Printf("Failed to print config: %v", err)
+			return nil, ExitFailed, false
+		}
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config:\n%v\n", err)
+		return nil, ExitFailed, false
+	}
+
+	if flags.PrintConfig {
+		return nil, ExitOK, false
+	}
+
+	return cfg, ExitOK, true
 }
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"api/internal/config"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const testToken = "test-token"
+
+// request sends a request with the token to the routes of the server. Only
+// requests answered before the database is reached work without one.
+func request(t *testing.T, method string, target string) *http.Response {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.Auth.Token = testToken
+
+	app, err := routesOnly(cfg)
+	if err != nil {
+		t.Fatalf("routes: %v", err)
+	}
+
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set(fiber.HeaderAuthorization, testToken)
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	return resp
+}
+
+func TestSearchValidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "sort outside the list", query: "pagination[sort]=ASC%3B--"},
+		{name: "limit outside the list", query: "pagination[limit]=1000"},
+		{name: "start below one", query: "pagination[startFrom]=0"},
+		{name: "snippet too long", query: "snippet[length]=100000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := request(t, fiber.MethodGet, "/api/pastes/search?"+tt.query)
+
+			if resp.StatusCode != fiber.StatusUnprocessableEntity {
+				t.Errorf("status = %d, want %d", resp.StatusCode, fiber.StatusUnprocessableEntity)
+			}
+		})
+	}
+}
+
+func TestSearchSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	// An invalid sort stops the search before the database.
+	request(t, fiber.MethodGet, "/api/pastes/search?pagination[sort]=ASC%3B--")
+
+	parents := map[string]string{}
+	names := map[trace.SpanID]string{}
+	for _, span := range recorder.Ended() {
+		names[span.SpanContext().SpanID()] = span.Name()
+	}
+	for _, span := range recorder.Ended() {
+		parents[span.Name()] = names[span.Parent().SpanID()]
+	}
+
+	want := map[string]string{
+		"querymap.decode":     "GET /api/pastes/search",
+		"PasteService.Search": "GET /api/pastes/search",
+		"validate":            "PasteService.Search",
+	}
+	for name, parent := range want {
+		got, ok := parents[name]
+		if !ok {
+			t.Errorf("no %s span", name)
+			continue
+		}
+		if got != parent {
+			t.Errorf("%s span is a child of %q, want %q", name, got, parent)
+		}
+	}
+}
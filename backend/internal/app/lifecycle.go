@@ -0,0 +1,126 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Exit codes of the process.
+const (
+	ExitOK = iota
+	// ExitFailed means a component failed to start or stopped on its own.
+	ExitFailed
+	// ExitUnclean means the shutdown did not finish within the drain timeout
+	// or a component failed to stop.
+	ExitUnclean
+)
+
+// Hook is a part of the application with a start and a stop. Start must not
+// block; a component that runs in the background reports a later failure
+// through Lifecycle.Fail.
+type Hook struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// Lifecycle starts hooks in the order they were added and stops them in the
+// reverse one, so the server stops taking requests before the workers and the
+// pool it depends on go away.
+type Lifecycle struct {
+	hooks           []Hook
+	started         int
+	failures        chan error
+	shutdownTimeout time.Duration
+	logger          *slog.Logger
+}
+
+func NewLifecycle(shutdownTimeout time.Duration, logger *slog.Logger) *Lifecycle {
+	return &Lifecycle{failures: make(chan error, 1), shutdownTimeout: shutdownTimeout, logger: logger}
+}
+
+func (l *Lifecycle) Append(hook Hook) {
+	l.hooks = append(l.hooks, hook)
+}
+
+// Fail stops the application because of a component that failed while
+// running. Only the first failure is kept.
+func (l *Lifecycle) Fail(name string, err error) {
+	select {
+	case l.failures <- fmt.Errorf("%s: %w", name, err):
+	default:
+	}
+}
+
+// Run starts every hook, waits for SIGINT, SIGTERM or a failure and stops the
+// started hooks again. It returns the exit code of the process.
+func (l *Lifecycle) Run() int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	code := ExitOK
+
+	if err := l.start(ctx); err != nil {
+		l.logger.Error("Failed to start", "error", err)
+		code = ExitFailed
+	} else {
+		select {
+		case <-ctx.Done():
+			l.logger.Info("Shutting down, waiting for requests to finish", "timeout", l.shutdownTimeout)
+		case err := <-l.failures:
+			l.logger.Error("Shutting down after a failure", "error", err)
+			code = ExitFailed
+		}
+	}
+
+	// A second signal kills the process instead of waiting for the drain.
+	stop()
+
+	if err := l.stop(); err != nil && code == ExitOK {
+		code = ExitUnclean
+	}
+
+	return code
+}
+
+func (l *Lifecycle) start(ctx context.Context) error {
+	for _, hook := range l.hooks {
+		if hook.Start != nil {
+			if err := hook.Start(ctx); err != nil {
+				return fmt.Errorf("%s: %w", hook.Name, err)
+			}
+		}
+		l.started++
+	}
+
+	return nil
+}
+
+// stop stops the started hooks in reverse order. They share the drain
+// timeout; a hook that overruns it does not keep the rest from stopping.
+func (l *Lifecycle) stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), l.shutdownTimeout)
+	defer cancel()
+
+	var errs []error
+
+	for i := l.started - 1; i >= 0; i-- {
+		hook := l.hooks[i]
+		if hook.Stop == nil {
+			continue
+		}
+
+		if err := hook.Stop(ctx); err != nil {
+			l.logger.Error("Failed to stop", "hook", hook.Name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", hook.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
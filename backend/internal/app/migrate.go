@@ -0,0 +1,124 @@
+package app
+
+import (
+	"api/internal/config"
+	"api/internal/database"
+	"api/internal/logging"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pressly/goose/v3"
+)
+
+const migrateUsage = "Usage: app migrate <up|down|status|redo> [flags]"
+
+// Migrate runs a migration command against the database from the config and
+// returns the exit code of the process.
+func Migrate(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return ExitFailed
+	}
+
+	command := args[0]
+	run, ok := migrateCommands[command]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown migrate command %q\n%s\n", command, migrateUsage)
+		return ExitFailed
+	}
+
+	cfg, code, ok := loadConfig("migrate "+command, args[1:])
+	if !ok {
+		return code
+	}
+
+	logger := logging.New(cfg.Log, os.Stderr)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	migrator, err := database.NewMigrator(cfg.Database.Dsn.Reveal(), cfg.Database.MigrationsTable)
+	if err != nil {
+		logger.Error("Failed to migrate", "error", err)
+		return ExitFailed
+	}
+	defer migrator.Close()
+
+	if err := run(ctx, migrator); err != nil {
+		logger.Error("Failed to migrate", "error", err)
+		return ExitFailed
+	}
+
+	return ExitOK
+}
+
+var migrateCommands = map[string]func(ctx context.Context, migrator database.Migrator) error{
+	"up": func(ctx context.Context, migrator database.Migrator) error {
+		results, err := migrator.Up(ctx)
+		printResults(results)
+		if err == nil && len(results) == 0 {
+			fmt.Println("No migrations to apply")
+		}
+		return err
+	},
+	"down": func(ctx context.Context, migrator database.Migrator) error {
+		result, err := migrator.Down(ctx)
+		if result != nil {
+			printResults([]*goose.MigrationResult{result})
+		}
+		return err
+	},
+	"redo": func(ctx context.Context, migrator database.Migrator) error {
+		results, err := migrator.Redo(ctx)
+		printResults(results)
+		return err
+	},
+	"status": func(ctx context.Context, migrator database.Migrator) error {
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(writer, "Applied At\tMigration")
+
+		for _, status := range statuses {
+			appliedAt := "Pending"
+			if status.State == goose.StateApplied {
+				appliedAt = status.AppliedAt.Local().Format(time.DateTime)
+			}
+			fmt.Fprintf(writer, "%s\t%s\n", appliedAt, status.Source.Path)
+		}
+
+		return writer.Flush()
+	},
+}
+
+// migrateUp applies pending migrations before the server starts. Replicas
+// starting together wait for each other on the advisory lock.
+func migrateUp(ctx context.Context, cfg *config.Config, logger *slog.Logger) error {
+	migrator, err := database.NewMigrator(cfg.Database.Dsn.Reveal(), cfg.Database.MigrationsTable)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	results, err := migrator.Up(ctx)
+	for _, result := range results {
+		logger.InfoContext(ctx, "Migration applied", "migration", result.Source.Path, "duration", result.Duration)
+	}
+
+	return err
+}
+
+func printResults(results []*goose.MigrationResult) {
+	for _, result := range results {
+		fmt.Println(result)
+	}
+}
@@ -0,0 +1,382 @@
+package app
+
+import (
+	"api/internal/config"
+	"api/internal/dtos"
+	"api/internal/middlewares"
+	"api/internal/models"
+	"api/internal/openapi"
+	"api/internal/responses"
+	"api/internal/services/health"
+	"api/internal/services/patch"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const openApiUsage = "Usage: app openapi"
+
+var openApiInfo = openapi.Info{
+	Title:   "Pastes API",
+	Version: "1.0.0",
+	Description: "Every request but the probes and the docs needs the secret token in Authorization. " +
+		"Responses are JSON unless Accept asks for MessagePack or YAML.",
+}
+
+// OpenApi prints the OpenAPI document of the routes. It fails when a route is
+// not described or a description has no route. It returns the exit code of
+// the process.
+func OpenApi(args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintln(os.Stderr, openApiUsage)
+		return ExitFailed
+	}
+
+	app, err := routesOnly(config.Default())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to register routes: %v\n", err)
+		return ExitFailed
+	}
+
+	doc, err := openapi.Build(openApiInfo, app.GetRoutes(true), endpoints)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Routes and the OpenAPI document diverge:\n%v\n", err)
+		return ExitFailed
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to print the document: %v\n", err)
+		return ExitFailed
+	}
+
+	return ExitOK
+}
+
+// routesOnly registers the routes of the server the way Run does, without a
+// database: the pool never connects unless a request comes.
+func routesOnly(cfg *config.Config) (*fiber.App, error) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	db, err := pgxpool.New(context.Background(), "")
+	if err != nil {
+		return nil, err
+	}
+
+	app := NewFiberApp(cfg, logger)
+	ConnectRoutes(app, db, cfg, health.NewChecker(readinessCheckTimeout), logger)
+
+	return app, nil
+}
+
+// jsonPatchOperation is an operation of a JSON Patch (RFC 6902). The body is
+// applied as is, the type only describes it.
+type jsonPatchOperation struct {
+	Op    string `json:"op" validate:"required,oneof=add remove replace move copy test"`
+	Path  string `json:"path" validate:"required"`
+	From  string `json:"from"`
+	Value any    `json:"value"`
+}
+
+type rawPasteQuery struct {
+	Download bool `json:"download"`
+}
+
+type executeQuery struct {
+	Pagination *dtos.PaginationDto `json:"pagination" validate:"omitempty"`
+}
+
+type liveReport struct {
+	Status string `json:"status" validate:"oneof=up"`
+}
+
+// patchBodies are the bodies PATCH takes: a merge patch of merge or a JSON
+// Patch.
+func patchBodies(merge any) map[string]any {
+	return map[string]any{
+		patch.ContentTypeMergePatch: merge,
+		patch.ContentTypeJsonPatch:  []jsonPatchOperation{},
+	}
+}
+
+var (
+	pastesPage = responses.PaginationResponse[responses.PasteItem]{}
+
+	transferBodies = map[string]any{
+		fiber.MIMEApplicationJSON: []models.PasteRecordModel{},
+		"application/x-ndjson":    "",
+		"text/csv":                "",
+	}
+)
+
+// endpoints describe every route of ConnectRoutes, keyed by the method and the
+// path as Fiber registers them. TestEndpoints fails when they diverge.
+var endpoints = map[string]openapi.Endpoint{
+	"GET /healthz": {
+		Summary:   "Liveness probe",
+		Tags:      []string{"health"},
+		Public:    true,
+		Responses: map[int]openapi.Result{fiber.StatusOK: {Content: openapi.Json(liveReport{})}},
+	},
+	"GET /readyz": {
+		Summary: "Readiness probe",
+		Tags:    []string{"health"},
+		Public:  true,
+		Responses: map[int]openapi.Result{
+			fiber.StatusOK:                 {Description: "Every check passed", Content: openapi.Json(health.Report{})},
+			fiber.StatusServiceUnavailable: {Description: "Some check failed", Content: openapi.Json(health.Report{})},
+		},
+	},
+	"GET /metrics":          {Hidden: true},
+	"GET /api/openapi.json": {Hidden: true},
+	"GET /api/docs":         {Hidden: true},
+	"GET /api/docs/:file":   {Hidden: true},
+
+	"GET /api/users/": {
+		Summary:     "Find a user",
+		Description: "At least one filter is required.",
+		Tags:        []string{"users"},
+		Query:       []any{dtos.UserFiltersDto{}},
+		Headers:     []string{fiber.HeaderIfNoneMatch},
+		Responses: map[int]openapi.Result{
+			fiber.StatusOK:          {Headers: []string{fiber.HeaderETag}, Content: openapi.Json(models.UserModel{})},
+			fiber.StatusNotModified: {},
+		},
+	},
+	"GET /api/users/suggest": {
+		Summary:   "Suggest users by username or display name",
+		Tags:      []string{"users"},
+		Query:     []any{dtos.SuggestQueryDto{}},
+		Responses: map[int]openapi.Result{fiber.StatusOK: {Content: openapi.Json([]models.SuggestionModel{})}},
+	},
+	"POST /api/users/": {
+		Summary: "Create a user",
+		Tags:    []string{"users"},
+		Headers: []string{middlewares.HeaderIdempotencyKey},
+		Body:    openapi.Json(dtos.UserDto{}),
+		Responses: map[int]openapi.Result{
+			fiber.StatusOK: {Headers: []string{fiber.HeaderETag, middlewares.HeaderIdempotentReplayed}, Content: openapi.Json(models.UserModel{})},
+		},
+	},
+	"GET /api/users/by-social/:socialId": {
+		Summary: "Get a user by social id",
+		Tags:    []string{"users"},
+		Headers: []string{fiber.HeaderIfNoneMatch},
+		Responses: map[int]openapi.Result{
+			fiber.StatusOK:          {Headers: []string{fiber.HeaderETag}, Content: openapi.Json(models.UserModel{})},
+			fiber.StatusNotModified: {},
+		},
+	},
+	"PUT /api/users/by-social/:socialId": {
+		Summary: "Replace a user by social id",
+		Tags:    []string{"users"},
+		Headers: []string{fiber.HeaderIfMatch},
+		Body:    openapi.Json(dtos.UpdateUserDto{}),
+		Responses: map[int]openapi.Result{
+			fiber.StatusOK: {Headers: []string{fiber.HeaderETag}, Content: openapi.Json(models.UserModel{})},
+		},
+	},
+	"PATCH /api/users/by-social/:socialId": {
+		Summary: "Patch a user by social id",
+		Tags:    []string{"users"},
+		Headers: []string{fiber.HeaderIfMatch},
+		Body:    patchBodies(dtos.PatchUserDto{}),
+		Responses: map[int]openapi.Result{
+			fiber.StatusOK: {Headers: []string{fiber.HeaderETag}, Content: openapi.Json(models.UserModel{})},
+		},
+	},
+	"DELETE /api/users/by-social/:socialId": {
+		Summary:   "Delete a user by social id",
+		Tags:      []string{"users"},
+		Headers:   []string{fiber.HeaderIfMatch},
+		Responses: map[int]openapi.Result{fiber.StatusNoContent: {}},
+	},
+	"GET /api/users/:id<int>": {
+		Summary: "Get a user",
+		Tags:    []string{"users"},
+		Headers: []string{fiber.HeaderIfNoneMatch},
+		Responses: map[int]openapi.Result{
+			fiber.StatusOK:          {Headers: []string{fiber.HeaderETag}, Content: openapi.Json(models.UserModel{})},
+			fiber.StatusNotModified: {},
+		},
+	},
+	"PUT /api/users/:id<int>": {
+		Summary: "Replace a user",
+		Tags:    []string{"users"},
+		Headers: []string{fiber.HeaderIfMatch},
+		Body:    openapi.Json(dtos.UpdateUserDto{}),
+		Responses: map[int]openapi.Result{
+			fiber.StatusOK: {Headers: []string{fiber.HeaderETag}, Content: openapi.Json(models.UserModel{})},
+		},
+	},
+	"PATCH /api/users/:id<int>": {
+		Summary: "Patch a user",
+		Tags:    []string{"users"},
+		Headers: []string{fiber.HeaderIfMatch},
+		Body:    patchBodies(dtos.PatchUserDto{}),
+		Responses: map[int]openapi.Result{
+			fiber.StatusOK: {Headers: []string{fiber.HeaderETag}, Content: openapi.Json(models.UserModel{})},
+		},
+	},
+	"DELETE /api/users/:id<int>": {
+		Summary:   "Delete a user",
+		Tags:      []string{"users"},
+		Headers:   []string{fiber.HeaderIfMatch},
+		Responses: map[int]openapi.Result{fiber.StatusNoContent: {}},
+	},
+
+	"GET /api/pastes/": {
+		Summary:     "Find a paste",
+		Description: "With `fields[...]` or `include=author` only the asked fields are sent, the author under `author`.",
+		Tags:        []string{"pastes"},
+		Query:       []any{dtos.PastesFilterDto{}, dtos.ResponseShapeDto{}},
+		Headers:     []string{fiber.HeaderIfNoneMatch},
+		Responses: map[int]openapi.Result{
+			fiber.StatusOK:          {Headers: []string{fiber.HeaderETag}, Content: openapi.Json(models.PasteModel{})},
+			fiber.StatusNotModified: {},
+		},
+	},
+	"GET /api/pastes/search": {
+		Summary:     "Search pastes",
+		Description: "Cursor pagination; `facets` adds counts of the matches by the listed facets.",
+		Tags:        []string{"pastes"},
+		Query:       []any{dtos.PastesSearchQueryDto{}, dtos.ResponseShapeDto{}},
+		Responses:   map[int]openapi.Result{fiber.StatusOK: {Content: openapi.Json(pastesPage)}},
+	},
+	"GET /api/pastes/suggest": {
+		Summary:   "Suggest pastes by title",
+		Tags:      []string{"pastes"},
+		Query:     []any{dtos.SuggestQueryDto{}},
+		Responses: map[int]openapi.Result{fiber.StatusOK: {Content: openapi.Json([]models.SuggestionModel{})}},
+	},
+	"GET /api/pastes/export": {
+		Summary:     "Export pastes",
+		Description: "Streams every matching paste as NDJSON (by default), a JSON array or CSV.",
+		Tags:        []string{"pastes"},
+		Query:       []any{dtos.ExportPastesQueryDto{}},
+		Responses: map[int]openapi.Result{
+			fiber.StatusOK: {Headers: []string{fiber.HeaderContentDisposition}, Content: transferBodies},
+		},
+	},
+	"POST /api/pastes/": {
+		Summary: "Create a paste",
+		Tags:    []string{"pastes"},
+		Headers: []string{middlewares.HeaderIdempotencyKey},
+		Body:    openapi.Json(dtos.PasteDto{}),
+		Responses: map[int]openapi.Result{
+			fiber.StatusCreated: {Headers: []string{fiber.HeaderETag, middlewares.HeaderIdempotentReplayed}, Content: openapi.Json(models.PasteModel{})},
+		},
+	},
+	"POST /api/pastes/batch": {
+		Summary:     "Create, update and delete pastes at once",
+		Description: "An atomic batch that fails answers with the status of the operation that rolled it back.",
+		Tags:        []string{"pastes"},
+		Headers:     []string{middlewares.HeaderIdempotencyKey},
+		Body:        openapi.Json(dtos.BatchPastesDto{}),
+		Responses: map[int]openapi.Result{
+			fiber.StatusOK: {Headers: []string{middlewares.HeaderIdempotentReplayed}, Content: openapi.Json(responses.BatchResult{})},
+		},
+	},
+	"POST /api/pastes/import": {
+		Summary:     "Import pastes",
+		Description: "The format comes from `format` or the Content-Type. Records that fail are reported by line.",
+		Tags:        []string{"pastes"},
+		Query:       []any{dtos.ImportPastesQueryDto{}},
+		Headers:     []string{middlewares.HeaderIdempotencyKey},
+		Body: map[string]any{
+			fiber.MIMEApplicationJSON: []dtos.ImportPasteDto{},
+			"application/x-ndjson":    "",
+			"text/csv":                "",
+		},
+		Responses: map[int]openapi.Result{
+			fiber.StatusOK: {Headers: []string{middlewares.HeaderIdempotentReplayed}, Content: openapi.Json(responses.ImportResult{})},
+		},
+	},
+	"GET /api/pastes/:id<int>": {
+		Summary: "Get a paste",
+		Tags:    []string{"pastes"},
+		Query:   []any{dtos.ResponseShapeDto{}},
+		Headers: []string{fiber.HeaderIfNoneMatch},
+		Responses: map[int]openapi.Result{
+			fiber.StatusOK:          {Headers: []string{fiber.HeaderETag}, Content: openapi.Json(models.PasteModel{})},
+			fiber.StatusNotModified: {},
+		},
+	},
+	"GET /api/pastes/:id<int>/raw": {
+		Summary: "Get the text of a paste",
+		Tags:    []string{"pastes"},
+		Query:   []any{rawPasteQuery{}},
+		Headers: []string{fiber.HeaderIfNoneMatch, fiber.HeaderIfModifiedSince},
+		Responses: map[int]openapi.Result{
+			fiber.StatusOK: {
+				Headers: []string{fiber.HeaderETag, fiber.HeaderLastModified, fiber.HeaderContentDisposition},
+				Content: map[string]any{fiber.MIMETextPlainCharsetUTF8: ""},
+			},
+			fiber.StatusNotModified: {},
+		},
+	},
+	"PUT /api/pastes/:id<int>": {
+		Summary: "Replace a paste",
+		Tags:    []string{"pastes"},
+		Headers: []string{fiber.HeaderIfMatch},
+		Body:    openapi.Json(dtos.UpdatePasteDto{}),
+		Responses: map[int]openapi.Result{
+			fiber.StatusOK: {Headers: []string{fiber.HeaderETag}, Content: openapi.Json(models.PasteModel{})},
+		},
+	},
+	"PATCH /api/pastes/:id<int>": {
+		Summary: "Patch a paste",
+		Tags:    []string{"pastes"},
+		Headers: []string{fiber.HeaderIfMatch},
+		Body:    patchBodies(dtos.PatchPasteDto{}),
+		Responses: map[int]openapi.Result{
+			fiber.StatusOK: {Headers: []string{fiber.HeaderETag}, Content: openapi.Json(models.PasteModel{})},
+		},
+	},
+	"DELETE /api/pastes/:id<int>": {
+		Summary:   "Delete a paste",
+		Tags:      []string{"pastes"},
+		Headers:   []string{fiber.HeaderIfMatch},
+		Responses: map[int]openapi.Result{fiber.StatusNoContent: {}},
+	},
+
+	"GET /api/saved-searches/": {
+		Summary:     "List saved searches of a user",
+		Description: "userId or socialId is required; name narrows the list to one search.",
+		Tags:        []string{"saved-searches"},
+		Query:       []any{dtos.SavedSearchFilterDto{}},
+		Responses:   map[int]openapi.Result{fiber.StatusOK: {Content: openapi.Json([]models.SavedSearchModel{})}},
+	},
+	"GET /api/saved-searches/execute": {
+		Summary:     "Run a saved search",
+		Description: "`pagination[...]` overrides the stored pagination field by field.",
+		Tags:        []string{"saved-searches"},
+		Query:       []any{dtos.SavedSearchFilterDto{}, executeQuery{}, dtos.ResponseShapeDto{}},
+		Responses:   map[int]openapi.Result{fiber.StatusOK: {Content: openapi.Json(pastesPage)}},
+	},
+	"POST /api/saved-searches/": {
+		Summary:   "Save a search",
+		Tags:      []string{"saved-searches"},
+		Headers:   []string{middlewares.HeaderIdempotencyKey},
+		Body:      openapi.Json(dtos.SavedSearchDto{}),
+		Responses: map[int]openapi.Result{fiber.StatusCreated: {Content: openapi.Json(models.SavedSearchModel{})}},
+	},
+	"PUT /api/saved-searches/:id<int>": {
+		Summary:   "Replace a saved search",
+		Tags:      []string{"saved-searches"},
+		Body:      openapi.Json(dtos.UpdateSavedSearchDto{}),
+		Responses: map[int]openapi.Result{fiber.StatusOK: {Content: openapi.Json(models.SavedSearchModel{})}},
+	},
+	"DELETE /api/saved-searches/:id<int>": {
+		Summary:   "Delete a saved search",
+		Tags:      []string{"saved-searches"},
+		Responses: map[int]openapi.Result{fiber.StatusNoContent: {}},
+	},
+}
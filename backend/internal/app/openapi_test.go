@@ -0,0 +1,20 @@
+package app
+
+import (
+	"api/internal/config"
+	"api/internal/openapi"
+	"testing"
+)
+
+// TestEndpoints fails when a route is not described in endpoints or a
+// description has no route.
+func TestEndpoints(t *testing.T) {
+	app, err := routesOnly(config.Default())
+	if err != nil {
+		t.Fatalf("routes: %v", err)
+	}
+
+	if _, err := openapi.Build(openApiInfo, app.GetRoutes(true), endpoints); err != nil {
+		t.Fatal(err)
+	}
+}
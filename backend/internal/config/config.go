@@ -0,0 +1,123 @@
+package config
+
+import "time"
+
+// Config is the configuration of the backend. Every setting can come from the
+// config file (`yaml`/`toml` key), the environment or .env (`env`) and a flag
+// named after the dotted file key, e.g. `--server.addr`.
+type Config struct {
+	Env         string            `yaml:"env" toml:"env" env:"APP_ENV" validate:"oneof=dev prod"`
+	Server      ServerConfig      `yaml:"server" toml:"server"`
+	Database    DatabaseConfig    `yaml:"database" toml:"database"`
+	Timeouts    TimeoutsConfig    `yaml:"timeouts" toml:"timeouts"`
+	Auth        AuthConfig        `yaml:"auth" toml:"auth"`
+	Cors        CorsConfig        `yaml:"cors" toml:"cors"`
+	Log         LogConfig         `yaml:"log" toml:"log"`
+	Idempotency IdempotencyConfig `yaml:"idempotency" toml:"idempotency"`
+	Metrics     MetricsConfig     `yaml:"metrics" toml:"metrics"`
+	Tracing     TracingConfig     `yaml:"tracing" toml:"tracing"`
+}
+
+type ServerConfig struct {
+	Addr string `yaml:"addr" toml:"addr" env:"LISTEN_ADDR" validate:"required,hostname_port"`
+	// ShutdownTimeout is how long in-flight requests and workers get to finish
+	// after SIGINT or SIGTERM.
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout" toml:"shutdownTimeout" env:"SHUTDOWN_TIMEOUT" validate:"gt=0"`
+}
+
+type DatabaseConfig struct {
+	// Dsn is shared with goose, hence the name of the variable.
+	Dsn      Secret `yaml:"dsn" toml:"dsn" env:"GOOSE_DBSTRING" validate:"required"`
+	MaxConns int32  `yaml:"maxConns" toml:"maxConns" env:"DB_MAX_CONNS" validate:"gte=1"`
+	MinConns int32  `yaml:"minConns" toml:"minConns" env:"DB_MIN_CONNS" validate:"gte=0,ltefield=MaxConns"`
+	// StatementTimeout is the longest a single query may run. It is above
+	// every request deadline and mostly guards queries nobody waits for anymore.
+	StatementTimeout time.Duration `yaml:"statementTimeout" toml:"statementTimeout" env:"DB_STATEMENT_TIMEOUT" validate:"gte=0"`
+	// ConnectTimeout is how long the backend waits for the database on start.
+	ConnectTimeout time.Duration `yaml:"connectTimeout" toml:"connectTimeout" env:"DB_CONNECT_TIMEOUT" validate:"gt=0"`
+	// MigrationsTable is shared with goose as well.
+	MigrationsTable string `yaml:"migrationsTable" toml:"migrationsTable" env:"GOOSE_TABLE" validate:"required"`
+	// MigrateOnStart applies pending migrations before the server starts.
+	MigrateOnStart bool `yaml:"migrateOnStart" toml:"migrateOnStart" env:"MIGRATE_ON_START"`
+}
+
+type TimeoutsConfig struct {
+	Request time.Duration `yaml:"request" toml:"request" env:"REQUEST_TIMEOUT" validate:"gt=0"`
+	Suggest time.Duration `yaml:"suggest" toml:"suggest" env:"SUGGEST_TIMEOUT" validate:"gt=0"`
+	Import  time.Duration `yaml:"import" toml:"import" env:"IMPORT_TIMEOUT" validate:"gt=0"`
+}
+
+type AuthConfig struct {
+	Token Secret `yaml:"token" toml:"token" env:"SECRET_API_TOKEN" validate:"required"`
+}
+
+// CorsConfig enables CORS for the listed origins; none disables it.
+type CorsConfig struct {
+	AllowOrigins []string `yaml:"allowOrigins" toml:"allowOrigins" env:"CORS_ALLOW_ORIGINS" validate:"dive,required"`
+}
+
+type LogConfig struct {
+	Level string `yaml:"level" toml:"level" env:"LOG_LEVEL" validate:"oneof=debug info warn error"`
+	// Format is json for log collectors or text for people.
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT" validate:"oneof=json text"`
+}
+
+type IdempotencyConfig struct {
+	KeysTTL time.Duration `yaml:"keysTtl" toml:"keysTtl" env:"IDEMPOTENCY_KEYS_TTL" validate:"gt=0"`
+	// PurgeInterval is how often expired keys are deleted. Claim drops an
+	// expired key on its own, this only keeps the table from growing.
+	PurgeInterval time.Duration `yaml:"purgeInterval" toml:"purgeInterval" env:"IDEMPOTENCY_PURGE_INTERVAL" validate:"gt=0"`
+}
+
+// MetricsConfig decides where /metrics is served: on its own admin address,
+// or on the main one behind its own token. With neither it is not served.
+type MetricsConfig struct {
+	Addr  string `yaml:"addr" toml:"addr" env:"METRICS_ADDR" validate:"omitempty,hostname_port"`
+	Token Secret `yaml:"token" toml:"token" env:"METRICS_TOKEN"`
+}
+
+// TracingConfig decides where spans go: nowhere, stdout, a file of JSON lines
+// or an OTLP collector over HTTP.
+type TracingConfig struct {
+	Exporter string `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER" validate:"oneof=none stdout file otlp"`
+	File     string `yaml:"file" toml:"file" env:"TRACING_FILE" validate:"required_if=Exporter file"`
+	// Endpoint is the full URL spans are posted to, path included.
+	Endpoint    string `yaml:"endpoint" toml:"endpoint" env:"TRACING_OTLP_ENDPOINT" validate:"required_if=Exporter otlp,omitempty,url"`
+	ServiceName string `yaml:"serviceName" toml:"serviceName" env:"TRACING_SERVICE_NAME" validate:"required"`
+}
+
+// Default returns the configuration used for everything no source sets.
+func Default() *Config {
+	return &Config{
+		Env: "dev",
+		Server: ServerConfig{
+			Addr:            ":8080",
+			ShutdownTimeout: 15 * time.Second,
+		},
+		Database: DatabaseConfig{
+			MaxConns:         10,
+			StatementTimeout: 30 * time.Second,
+			ConnectTimeout:   time.Minute,
+			MigrationsTable:  "migrations",
+		},
+		Timeouts: TimeoutsConfig{
+			Request: 10 * time.Second,
+			// Discord waits only 3s for autocomplete.
+			Suggest: 2 * time.Second,
+			Import:  2 * time.Minute,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		Idempotency: IdempotencyConfig{
+			KeysTTL:       24 * time.Hour,
+			PurgeInterval: time.Hour,
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			Endpoint:    "http://localhost:4318/v1/traces",
+			ServiceName: "pastcollection-backend",
+		},
+	}
+}
@@ -0,0 +1,261 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+const dotenvFile = ".env"
+
+// Flags are the command line options. Besides --config and --print-config
+// every setting has a flag overriding the other sources.
+type Flags struct {
+	File        string
+	PrintConfig bool
+	values      map[string]string
+}
+
+// ParseFlags parses the command line. It returns flag.ErrHelp for -h.
+func ParseFlags(name string, args []string) (*Flags, error) {
+	flags := &Flags{values: map[string]string{}}
+
+	set := flag.NewFlagSet(name, flag.ContinueOnError)
+	set.StringVar(&flags.File, "config", "", "YAML or TOML config file, also CONFIG_FILE")
+	set.BoolVar(&flags.PrintConfig, "print-config", false, "print the resulting config with secrets redacted and exit")
+
+	for _, setting := range settings(Default()) {
+		record := func(value string) error {
+			flags.values[setting.path] = value
+			return nil
+		}
+
+		if setting.value.Kind() == reflect.Bool {
+			set.BoolFunc(setting.path, "overrides "+setting.env, record)
+		} else {
+			set.Func(setting.path, "overrides "+setting.env, record)
+		}
+	}
+
+	if err := set.Parse(args); err != nil {
+		return nil, err
+	}
+
+	return flags, nil
+}
+
+// Load builds the config from, in increasing precedence, the defaults, the
+// config file, .env, the environment and the flags, and validates it. Every
+// problem found is reported in the returned error; the config is returned
+// anyway so that --print-config can show it.
+func Load(flags *Flags) (*Config, error) {
+	cfg := Default()
+	var errs []error
+
+	dotenv, err := godotenv.Read(dotenvFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		errs = append(errs, fmt.Errorf("%s: %w", dotenvFile, err))
+	}
+
+	file := flags.File
+	if file == "" {
+		file = os.Getenv("CONFIG_FILE")
+	}
+	if file == "" {
+		file = dotenv["CONFIG_FILE"]
+	}
+
+	if file != "" {
+		if err := loadFile(cfg, file); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	errs = append(errs, applyEnv(cfg, func(key string) (string, bool) {
+		value, ok := dotenv[key]
+		return value, ok
+	})...)
+	errs = append(errs, applyEnv(cfg, os.LookupEnv)...)
+	errs = append(errs, applyFlags(cfg, flags.values)...)
+	errs = append(errs, validate(cfg)...)
+
+	return cfg, errors.Join(errs...)
+}
+
+// Print writes the config as YAML with secrets redacted.
+func (c *Config) Print(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(c); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+func loadFile(cfg *Config, file string) error {
+	switch filepath.Ext(file) {
+	case ".yaml", ".yml":
+		reader, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+
+		decoder := yaml.NewDecoder(reader)
+		decoder.KnownFields(true)
+
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	case ".toml":
+		meta, err := toml.DecodeFile(file, cfg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("%s: unknown keys %v", file, undecoded)
+		}
+	default:
+		return fmt.Errorf("%s: config file must be .yaml, .yml or .toml", file)
+	}
+
+	return nil
+}
+
+// applyEnv sets every setting whose variable is found by lookup. A variable
+// `NAME_FILE` points to a file holding the value of `NAME`, for secrets mounted
+// as files.
+func applyEnv(cfg *Config, lookup func(key string) (string, bool)) []error {
+	var errs []error
+
+	for _, setting := range settings(cfg) {
+		value, ok := lookup(setting.env)
+		file, fromFile := lookup(setting.env + "_FILE")
+
+		if ok && fromFile {
+			errs = append(errs, fmt.Errorf("%s: both %s and %s_FILE are set", setting.env, setting.env, setting.env))
+			continue
+		}
+
+		if fromFile {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s_FILE: %w", setting.env, err))
+				continue
+			}
+			value, ok = strings.TrimRight(string(content), "\r\n"), true
+		}
+
+		if !ok {
+			continue
+		}
+
+		if err := parse(setting.value, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", setting.env, err))
+		}
+	}
+
+	return errs
+}
+
+func applyFlags(cfg *Config, values map[string]string) []error {
+	var errs []error
+
+	for _, setting := range settings(cfg) {
+		value, ok := values[setting.path]
+		if !ok {
+			continue
+		}
+
+		if err := parse(setting.value, value); err != nil {
+			errs = append(errs, fmt.Errorf("--%s: %w", setting.path, err))
+		}
+	}
+
+	return errs
+}
+
+// setting is a single value of the config, addressed by its dotted file key.
+type setting struct {
+	path  string
+	env   string
+	value reflect.Value
+}
+
+func settings(cfg *Config) []setting {
+	return collect(reflect.ValueOf(cfg).Elem(), "", nil)
+}
+
+func collect(value reflect.Value, prefix string, found []setting) []setting {
+	for i := range value.NumField() {
+		field := value.Type().Field(i)
+		path := prefix + field.Tag.Get("yaml")
+
+		if field.Type.Kind() == reflect.Struct {
+			found = collect(value.Field(i), path+".", found)
+			continue
+		}
+
+		found = append(found, setting{path: path, env: field.Tag.Get("env"), value: value.Field(i)})
+	}
+
+	return found
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// parse sets value from its string form. Lists are comma separated.
+func parse(value reflect.Value, raw string) error {
+	if value.Type() == durationType {
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		value.SetInt(int64(duration))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		number, err := strconv.ParseInt(raw, 10, value.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		value.SetInt(number)
+	case reflect.Bool:
+		flag, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		value.SetBool(flag)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", value.Type())
+	}
+
+	return nil
+}
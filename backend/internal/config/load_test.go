@@ -0,0 +1,200 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// unsetEnv clears the variables the tests set, restoring them afterwards.
+func unsetEnv(t *testing.T, keys ...string) {
+	t.Helper()
+
+	for _, key := range keys {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   string
+		dotenv string
+		env    map[string]string
+		args   []string
+		want   string
+	}{
+		{
+			name: "defaults",
+			want: "info",
+		},
+		{
+			name: "file over defaults",
+			file: "log:\n  level: debug\n",
+			want: "debug",
+		},
+		{
+			name:   ".env over file",
+			file:   "log:\n  level: debug\n",
+			dotenv: "LOG_LEVEL=warn\n",
+			want:   "warn",
+		},
+		{
+			name:   "environment over .env",
+			file:   "log:\n  level: debug\n",
+			dotenv: "LOG_LEVEL=warn\n",
+			env:    map[string]string{"LOG_LEVEL": "error"},
+			want:   "error",
+		},
+		{
+			name:   "flags over everything",
+			file:   "log:\n  level: debug\n",
+			dotenv: "LOG_LEVEL=warn\n",
+			env:    map[string]string{"LOG_LEVEL": "error"},
+			args:   []string{"--log.level", "info"},
+			want:   "info",
+		},
+		{
+			name:   ".env names the file",
+			dotenv: "CONFIG_FILE=from-dotenv.yaml\n",
+			want:   "debug",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Chdir(dir)
+
+			unsetEnv(t, "LOG_LEVEL", "CONFIG_FILE", "GOOSE_DBSTRING_FILE", "SECRET_API_TOKEN_FILE")
+			t.Setenv("GOOSE_DBSTRING", "postgres://localhost/test")
+			t.Setenv("SECRET_API_TOKEN", "token")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			if err := os.WriteFile("from-dotenv.yaml", []byte("log:\n  level: debug\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if tt.dotenv != "" {
+				if err := os.WriteFile(dotenvFile, []byte(tt.dotenv), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			args := tt.args
+			if tt.file != "" {
+				file := filepath.Join(dir, "config.yaml")
+				if err := os.WriteFile(file, []byte(tt.file), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				args = append([]string{"--config", file}, args...)
+			}
+
+			flags, err := ParseFlags("test", args)
+			if err != nil {
+				t.Fatalf("ParseFlags: %v", err)
+			}
+
+			cfg, err := Load(flags)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if cfg.Log.Level != tt.want {
+				t.Errorf("log level = %q, want %q", cfg.Log.Level, tt.want)
+			}
+			if cfg.Server.Addr != Default().Server.Addr {
+				t.Errorf("server addr = %q, want the default %q", cfg.Server.Addr, Default().Server.Addr)
+			}
+		})
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "token")
+	if err := os.WriteFile(secret, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		vars map[string]string
+		want string
+		err  string
+	}{
+		{
+			name: "plain variable",
+			vars: map[string]string{"SECRET_API_TOKEN": "plain"},
+			want: "plain",
+		},
+		{
+			name: "file variable without the trailing newline",
+			vars: map[string]string{"SECRET_API_TOKEN_FILE": secret},
+			want: "from-file",
+		},
+		{
+			name: "both set",
+			vars: map[string]string{"SECRET_API_TOKEN": "plain", "SECRET_API_TOKEN_FILE": secret},
+			err:  "both SECRET_API_TOKEN and SECRET_API_TOKEN_FILE are set",
+		},
+		{
+			name: "missing file",
+			vars: map[string]string{"SECRET_API_TOKEN_FILE": filepath.Join(dir, "missing")},
+			err:  "SECRET_API_TOKEN_FILE",
+		},
+		{
+			name: "invalid value",
+			vars: map[string]string{"DB_MAX_CONNS": "many"},
+			err:  `DB_MAX_CONNS: invalid number "many"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			errs := applyEnv(cfg, func(key string) (string, bool) {
+				value, ok := tt.vars[key]
+				return value, ok
+			})
+
+			if tt.err != "" {
+				if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.err) {
+					t.Fatalf("errs = %v, want one containing %q", errs, tt.err)
+				}
+				return
+			}
+
+			if len(errs) > 0 {
+				t.Fatalf("errs = %v", errs)
+			}
+			if cfg.Auth.Token.Reveal() != tt.want {
+				t.Errorf("token = %q, want %q", cfg.Auth.Token.Reveal(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	cfg := Default()
+	errs := applyFlags(cfg, map[string]string{
+		"timeouts.request":        "3s",
+		"cors.allowOrigins":       "https://a.test, ,https://b.test",
+		"database.migrateOnStart": "true",
+	})
+	if len(errs) > 0 {
+		t.Fatalf("errs = %v", errs)
+	}
+
+	if cfg.Timeouts.Request.String() != "3s" {
+		t.Errorf("request timeout = %s, want 3s", cfg.Timeouts.Request)
+	}
+	if strings.Join(cfg.Cors.AllowOrigins, "|") != "https://a.test|https://b.test" {
+		t.Errorf("allow origins = %q", cfg.Cors.AllowOrigins)
+	}
+	if !cfg.Database.MigrateOnStart {
+		t.Error("migrate on start = false, want true")
+	}
+}
@@ -0,0 +1,22 @@
+package config
+
+const redacted = "[redacted]"
+
+// Secret is a setting that must not end up in logs or in --print-config.
+type Secret string
+
+// Reveal returns the actual value.
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) MarshalYAML() (any, error) {
+	return s.String(), nil
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var validatorInstance = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return field.Tag.Get("yaml")
+	})
+	return v
+}
+
+// validate returns an error per invalid setting, naming both the file key and
+// the variable.
+func validate(cfg *Config) []error {
+	err := validatorInstance.Struct(cfg)
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return nil
+	}
+
+	envs := map[string]string{}
+	for _, setting := range settings(cfg) {
+		envs[setting.path] = setting.env
+	}
+
+	errs := make([]error, 0, len(fieldErrs))
+	for _, fieldErr := range fieldErrs {
+		// The namespace starts with the name of the root struct.
+		_, path, _ := strings.Cut(fieldErr.Namespace(), ".")
+		key, _, _ := strings.Cut(path, "[")
+
+		errs = append(errs, fmt.Errorf("%s (%s): %s", path, envs[key], describe(fieldErr)))
+	}
+
+	return errs
+}
+
+func describe(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "oneof":
+		return "must be one of " + fieldErr.Param()
+	case "gt":
+		return "must be greater than " + fieldErr.Param()
+	case "gte":
+		return "must be at least " + fieldErr.Param()
+	case "ltefield":
+		// The param is the Go name of the other field, the file key is the same
+		// in lower camel case.
+		param := fieldErr.Param()
+		return "must not be greater than " + strings.ToLower(param[:1]) + param[1:]
+	case "required_if":
+		// The param is the Go name of the other field and the value it has.
+		field, value, _ := strings.Cut(fieldErr.Param(), " ")
+		return "is required when " + strings.ToLower(field[:1]) + field[1:] + " is " + value
+	case "url":
+		return "must be a URL"
+	case "hostname_port":
+		return "must be host:port"
+	default:
+		return "fails the " + fieldErr.Tag() + " check"
+	}
+}
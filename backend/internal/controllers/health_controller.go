@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"api/internal/services/health"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HealthController interface {
+	Live(c *fiber.Ctx) error
+	Ready(c *fiber.Ctx) error
+}
+
+type healthController struct {
+	checker *health.Checker
+}
+
+func NewHealthController(checker *health.Checker) HealthController {
+	return &healthController{checker: checker}
+}
+
+// Live answers as long as the process serves requests at all.
+func (h *healthController) Live(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": health.StatusUp})
+}
+
+// Ready runs every check and answers 503 unless all of them pass.
+func (h *healthController) Ready(c *fiber.Ctx) error {
+	report := h.checker.Run(c.UserContext())
+
+	status := fiber.StatusOK
+	if report.Status != health.StatusUp {
+		status = fiber.StatusServiceUnavailable
+	}
+
+	return c.Status(status).JSON(report)
+}
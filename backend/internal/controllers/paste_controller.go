@@ -1,44 +1,422 @@
 package controllers
 
 import (
+	"api/internal/dtos"
+	"api/internal/enums"
+	"api/internal/models"
+	"api/internal/responses"
 	"api/internal/services"
+	"api/internal/services/etag"
+	"api/internal/services/transfer"
+	"api/internal/services/translit"
+	"api/internal/services/validators"
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+const exportFlushEvery = 100
+
 type PasteController interface {
 	FindPaste(c *fiber.Ctx) error
+	FindOnePaste(c *fiber.Ctx) error
 	SearchPaste(c *fiber.Ctx) error
+	SuggestPaste(c *fiber.Ctx) error
 	CreatePaste(c *fiber.Ctx) error
 	DeletePaste(c *fiber.Ctx) error
 	UpdatePaste(c *fiber.Ctx) error
+	PatchPaste(c *fiber.Ctx) error
+	RawPaste(c *fiber.Ctx) error
+	BatchPastes(c *fiber.Ctx) error
+	ExportPastes(c *fiber.Ctx) error
+	ImportPastes(c *fiber.Ctx) error
 }
 
 type pasteController struct {
 	pasteService services.PasteService
+	logger       *slog.Logger
 }
 
-
-func NewPasteController(pasteService services.PasteService) PasteController {
-	return &pasteController{pasteService: pasteService}
+func NewPasteController(pasteService services.PasteService, logger *slog.Logger) PasteController {
+	return &pasteController{pasteService: pasteService, logger: logger}
 }
 
 func (p *pasteController) FindPaste(c *fiber.Ctx) error {
-	return p.pasteService.Find(c)
+	filter, err := parseQuery[dtos.PastesFilterDto](c)
+	if err != nil {
+		return err
+	}
+
+	shape, err := parseQuery[dtos.ResponseShapeDto](c)
+	if err != nil {
+		return err
+	}
+
+	view, err := p.pasteService.Find(c.UserContext(), filter, shape)
+	if err != nil {
+		return err
+	}
+
+	return p.sendView(c, view)
+}
+
+func (p *pasteController) FindOnePaste(c *fiber.Ctx) error {
+	id, err := pasteId(c)
+	if err != nil {
+		return err
+	}
+
+	shape, err := parseQuery[dtos.ResponseShapeDto](c)
+	if err != nil {
+		return err
+	}
+
+	view, err := p.pasteService.FindOne(c.UserContext(), id, shape)
+	if err != nil {
+		return err
+	}
+
+	return p.sendView(c, view)
 }
 
 func (p *pasteController) SearchPaste(c *fiber.Ctx) error {
-	return p.pasteService.Search(c)
+	queryObj, err := parseQuery[dtos.PastesSearchQueryDto](c)
+	if err != nil {
+		return err
+	}
+
+	shape, err := parseQuery[dtos.ResponseShapeDto](c)
+	if err != nil {
+		return err
+	}
+
+	result, err := p.pasteService.Search(c.UserContext(), queryObj, shape)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+func (p *pasteController) SuggestPaste(c *fiber.Ctx) error {
+	queryObj, err := parseQuery[dtos.SuggestQueryDto](c)
+	if err != nil {
+		return err
+	}
+
+	suggestions, err := p.pasteService.Suggest(c.UserContext(), queryObj)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(suggestions)
 }
 
 func (p *pasteController) DeletePaste(c *fiber.Ctx) error {
-	return p.pasteService.Delete(c)
+	id, err := pasteId(c)
+	if err != nil {
+		return err
+	}
+
+	if err := p.pasteService.Delete(c.UserContext(), id, ifMatch(c)); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
 }
 
 func (p *pasteController) UpdatePaste(c *fiber.Ctx) error {
-	return p.pasteService.Update(c)
+	var body dtos.UpdatePasteDto
+
+	id, err := pasteId(c)
+	if err != nil {
+		return err
+	}
+
+	if err := parseBody(c, &body); err != nil {
+		return err
+	}
+
+	newPaste, err := p.pasteService.Update(c.UserContext(), id, &body, ifMatch(c))
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderETag, etag.Format(newPaste.Version))
+
+	return c.Status(fiber.StatusOK).JSON(newPaste)
+}
+
+func (p *pasteController) PatchPaste(c *fiber.Ctx) error {
+	id, err := pasteId(c)
+	if err != nil {
+		return err
+	}
+
+	newPaste, err := p.pasteService.Patch(c.UserContext(), id, c.Body(), c.Get(fiber.HeaderContentType), ifMatch(c))
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderETag, etag.Format(newPaste.Version))
+
+	return c.Status(fiber.StatusOK).JSON(newPaste)
 }
 
 func (p *pasteController) CreatePaste(c *fiber.Ctx) error {
-	return p.pasteService.Create(c)
+	var body dtos.PasteDto
+
+	if err := parseBody(c, &body); err != nil {
+		return err
+	}
+
+	newPaste, err := p.pasteService.Create(c.UserContext(), &body)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderETag, etag.Format(newPaste.Version))
+
+	return c.Status(fiber.StatusCreated).JSON(newPaste)
+}
+
+// BatchPastes answers with a result per operation. A failed atomic batch
+// takes the status of the operation that rolled it back.
+func (p *pasteController) BatchPastes(c *fiber.Ctx) error {
+	var body dtos.BatchPastesDto
+
+	if err := parseBody(c, &body); err != nil {
+		return err
+	}
+
+	report, err := p.pasteService.Batch(c.UserContext(), &body)
+	if err != nil {
+		return err
+	}
+
+	results := make([]*responses.BatchOperationResult, len(report.Outcomes))
+	for i, outcome := range report.Outcomes {
+		results[i] = &responses.BatchOperationResult{Index: i, Op: outcome.Op, Item: outcome.Item}
+
+		if outcome.Err != nil {
+			results[i].Error = p.problem(c, outcome.Err)
+			results[i].Status = results[i].Error.Status
+			continue
+		}
+
+		switch outcome.Op {
+		case enums.BatchCreate:
+			results[i].Status = fiber.StatusCreated
+		case enums.BatchUpdate:
+			results[i].Status = fiber.StatusOK
+		case enums.BatchDelete:
+			results[i].Status = fiber.StatusNoContent
+		}
+	}
+
+	status := fiber.StatusOK
+	if report.Failed != nil {
+		status = responses.FromError(report.Failed.Err).Status
+	}
+
+	return c.Status(status).JSON(responses.NewBatchResult(report.Mode, results))
+}
+
+// ExportPastes streams every paste matching `filter[...]` as a JSON array,
+// NDJSON or CSV (`format`, NDJSON by default).
+func (p *pasteController) ExportPastes(c *fiber.Ctx) error {
+	queryObj, err := parseQuery[dtos.ExportPastesQueryDto](c)
+	if err != nil {
+		return err
+	}
+
+	if violations := validators.Validate(c.UserContext(), queryObj); violations != nil {
+		return violations
+	}
+
+	format := enums.TransferNdjson
+	if queryObj.Format != nil {
+		format = *queryObj.Format
+	}
+
+	c.Set(fiber.HeaderContentType, transfer.ContentType(format))
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="pastes.%s"`, format))
+
+	// The stream is written after the handler returns, so it must not be bound
+	// to the deadline of the request. A client that goes away makes the writes
+	// fail, which stops the export.
+	ctx := context.WithoutCancel(c.UserContext())
+
+	// The status went out with the first chunk, so a failure halfway can only
+	// be told by cutting the connection before the final chunk: the client
+	// then sees a broken transfer instead of a complete-looking file.
+	conn := c.Context().Conn()
+	abort := func(err error) {
+		p.logger.ErrorContext(ctx, "Export failed", "error", err)
+		if err := conn.Close(); err != nil {
+			p.logger.DebugContext(ctx, "Cannot close the connection of a failed export", "error", err)
+		}
+	}
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		writer := transfer.NewWriter(w, format)
+		written := 0
+
+		err := p.pasteService.Export(ctx, queryObj.Filter, func(record *models.PasteRecordModel) error {
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+
+			written++
+			if written%exportFlushEvery == 0 {
+				if err := writer.Flush(); err != nil {
+					return err
+				}
+				return w.Flush()
+			}
+			return nil
+		})
+
+		if err != nil {
+			abort(err)
+			return
+		}
+
+		if err := writer.Close(); err != nil {
+			abort(err)
+			return
+		}
+
+		if err := w.Flush(); err != nil {
+			abort(err)
+		}
+	})
+
+	return nil
+}
+
+// ImportPastes creates pastes from a JSON array, NDJSON or CSV body. The format
+// comes from `format` or the Content-Type; `onConflict` decides what happens to
+// records whose title is already taken (skip by default).
+func (p *pasteController) ImportPastes(c *fiber.Ctx) error {
+	queryObj, err := parseQuery[dtos.ImportPastesQueryDto](c)
+	if err != nil {
+		return err
+	}
+
+	if violations := validators.Validate(c.UserContext(), queryObj); violations != nil {
+		return violations
+	}
+
+	format := transfer.FormatOf(c.Get(fiber.HeaderContentType))
+	if queryObj.Format != nil {
+		format = *queryObj.Format
+	}
+
+	strategy := enums.ConflictSkip
+	if queryObj.OnConflict != nil {
+		strategy = *queryObj.OnConflict
+	}
+
+	report, err := p.pasteService.Import(c.UserContext(), transfer.NewReader(c.Body(), format), strategy)
+	if err != nil {
+		return err
+	}
+
+	result := responses.NewImportResult()
+	result.Created = report.Created
+	result.Overwritten = report.Overwritten
+	result.Renamed = report.Renamed
+	result.Skipped = report.Skipped
+
+	for _, failure := range report.Failures {
+		result.Fail(failure.Line, p.problem(c, failure.Err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+// RawPaste serves the paste text as text/plain. With `?download=1` it is sent
+// as an attachment named after the transliterated title.
+func (p *pasteController) RawPaste(c *fiber.Ctx) error {
+	id, err := pasteId(c)
+	if err != nil {
+		return err
+	}
+
+	view, err := p.pasteService.FindOne(c.UserContext(), id, nil)
+	if err != nil {
+		return err
+	}
+
+	existed := view.Paste
+
+	c.Set(fiber.HeaderETag, etag.Format(existed.Version))
+	c.Set(fiber.HeaderLastModified, existed.UpdatedAt.UTC().Format(http.TimeFormat))
+
+	if notModifiedSince(c, existed.Version, existed.UpdatedAt) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	if c.QueryBool("download") {
+		filename := translit.Filename(existed.Title, fmt.Sprintf("paste-%d", existed.Id)) + ".txt"
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`,
+			filename, encodeExtValue(existed.Title+".txt")))
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+
+	return c.Status(fiber.StatusOK).SendString(existed.Paste)
+}
+
+func (p *pasteController) sendView(c *fiber.Ctx, view *services.PasteView) error {
+	c.Set(fiber.HeaderETag, etag.Format(view.Paste.Version))
+
+	if notModified(c, view.Paste.Version) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(view.Body)
+}
+
+// pasteId reads the `:id` route parameter.
+func pasteId(c *fiber.Ctx) (int, error) {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return 0, responses.NewBadRequestError("Invalid paste id")
+	}
+
+	return id, nil
+}
+
+// encodeExtValue percent-encodes everything but RFC 8187 attr-chars, for the
+// `filename*` parameter of Content-Disposition.
+func encodeExtValue(value string) string {
+	var builder strings.Builder
+
+	for _, b := range []byte(value) {
+		if b < 0x80 && (b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || strings.IndexByte("!#$&+-.^_`|~", b) >= 0) {
+			builder.WriteByte(b)
+			continue
+		}
+		fmt.Fprintf(&builder, "%%%02X", b)
+	}
+
+	return builder.String()
+}
+
+// problem presents the error of a single batch operation or import record,
+// which never reaches the error handler, and logs it the same way if it is
+// unexpected.
+func (p *pasteController) problem(c *fiber.Ctx, err error) *responses.Problem {
+	problem := responses.FromError(err)
+	if problem.Status >= fiber.StatusInternalServerError {
+		p.logger.ErrorContext(c.UserContext(), "Operation failed", "error", err)
+	}
+	return problem
 }
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"api/internal/dtos"
+	"api/internal/middlewares"
+	"api/internal/models"
+	"api/internal/repositories"
+	"api/internal/services"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// exportingPastes exports count pastes and then fails with err, if any.
+type exportingPastes struct {
+	repositories.PasteRepository
+	count int
+	err   error
+}
+
+func (e *exportingPastes) Export(_ context.Context, _ *dtos.PastesFilterDto, fn func(record *models.PasteRecordModel) error) error {
+	for i := 1; i <= e.count; i++ {
+		if err := fn(&models.PasteRecordModel{Id: i, Title: "title", Paste: "paste", SocialId: "1"}); err != nil {
+			return err
+		}
+	}
+	return e.err
+}
+
+func TestExportPastes(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name      string
+		format    string
+		err       error
+		truncated bool
+	}{
+		{name: "ndjson", format: "ndjson"},
+		{name: "csv", format: "csv"},
+		{name: "json", format: "json"},
+		{name: "ndjson failing halfway", format: "ndjson", err: errors.New("canceling statement"), truncated: true},
+		{name: "csv failing halfway", format: "csv", err: errors.New("canceling statement"), truncated: true},
+		{name: "json failing halfway", format: "json", err: errors.New("canceling statement"), truncated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pastes := &exportingPastes{count: 250, err: tt.err}
+			uow := &fakeUnitOfWork{repositories: &repositories.Repositories{Pastes: pastes}}
+			controller := NewPasteController(services.NewPasteService(uow, pastes, nil, logger), logger)
+
+			app := fiber.New(fiber.Config{ErrorHandler: middlewares.NewErrorHandler(logger), DisableStartupMessage: true})
+			app.Get("/api/pastes/export", controller.ExportPastes)
+
+			// A real connection, closing the one of app.Test does nothing.
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			go app.Listener(listener)
+			t.Cleanup(func() { app.Shutdown() })
+
+			// The records are copied to the connection on another goroutine, so
+			// a failed export breaks either the response or its body.
+			resp, err := http.Get("http://" + listener.Addr().String() + "/api/pastes/export?format=" + tt.format)
+			if err == nil {
+				defer resp.Body.Close()
+				if resp.StatusCode != fiber.StatusOK {
+					t.Fatalf("status = %d, want %d", resp.StatusCode, fiber.StatusOK)
+				}
+				_, err = io.ReadAll(resp.Body)
+			}
+
+			if tt.truncated && err == nil {
+				t.Error("a failed export looks complete")
+			}
+			if !tt.truncated && err != nil {
+				t.Errorf("export: %v", err)
+			}
+		})
+	}
+}
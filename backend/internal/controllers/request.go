@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"api/internal/responses"
+	"api/internal/services"
+	"api/internal/services/etag"
+	"api/internal/services/querymap"
+	"api/internal/tracing"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// parseQuery maps the query string of the request onto T, in a span named
+// querymap.decode.
+func parseQuery[T any](c *fiber.Ctx) (*T, error) {
+	_, span := tracing.Start(c.UserContext(), "querymap.decode")
+	queryObj, err := querymap.FromURLStringToStruct[T](c.BaseURL() + c.OriginalURL())
+	tracing.End(span, err)
+	if err != nil {
+		slog.DebugContext(c.UserContext(), "Cannot parse query", "error", err)
+		return nil, responses.NewBadRequestError("Failed to parse query...")
+	}
+
+	return queryObj, nil
+}
+
+// parseBody decodes the body of the request into out, in a span named body.parse.
+func parseBody(c *fiber.Ctx, out any) error {
+	_, span := tracing.Start(c.UserContext(), "body.parse")
+	err := c.BodyParser(out)
+	tracing.End(span, err)
+	if err != nil {
+		return responses.NewBadRequestError("Cannot parse body...")
+	}
+
+	return nil
+}
+
+// ifMatch turns the If-Match header into a precondition: a write has to find
+// the version the client saw, or any version when the header is absent. Weak
+// tags never match, the comparison is strong.
+func ifMatch(c *fiber.Ctx) services.Precondition {
+	header := c.Get(fiber.HeaderIfMatch)
+
+	return func(current int) (*int, bool) {
+		if header == "" {
+			return nil, true
+		}
+
+		if !etag.MatchesStrong(header, current) {
+			return nil, false
+		}
+
+		return &current, true
+	}
+}
+
+// notModified reports whether the If-None-Match header already lists the current version.
+func notModified(c *fiber.Ctx, current int) bool {
+	header := c.Get(fiber.HeaderIfNoneMatch)
+	return header != "" && etag.MatchesWeak(header, current)
+}
+
+// notModifiedSince is notModified for responses that also carry Last-Modified.
+// If-None-Match wins over If-Modified-Since, as RFC 9110 requires.
+func notModifiedSince(c *fiber.Ctx, current int, modified time.Time) bool {
+	if c.Get(fiber.HeaderIfNoneMatch) != "" {
+		return notModified(c, current)
+	}
+
+	since, err := http.ParseTime(c.Get(fiber.HeaderIfModifiedSince))
+	return err == nil && !modified.Truncate(time.Second).After(since)
+}
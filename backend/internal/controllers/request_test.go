@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"api/internal/dtos"
+	"api/internal/enums"
+	"api/internal/middlewares"
+	"api/internal/models"
+	"api/internal/repositories"
+	"api/internal/services"
+	"context"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// fakeUnitOfWork runs fn with the fake repositories, without a transaction.
+type fakeUnitOfWork struct {
+	repositories *repositories.Repositories
+}
+
+func (u *fakeUnitOfWork) Do(_ context.Context, fn func(r *repositories.Repositories) error) error {
+	return fn(u.repositories)
+}
+
+func (u *fakeUnitOfWork) Stream(_ context.Context, fn func(r *repositories.Repositories) error) error {
+	return fn(u.repositories)
+}
+
+// fakePastes holds a single paste at version 3.
+type fakePastes struct {
+	repositories.PasteRepository
+}
+
+func (f *fakePastes) FindOne(_ context.Context, filter *dtos.PastesFilterDto, _ *dtos.PaginationDto) (*models.PasteModel, error) {
+	return &models.PasteModel{Id: *filter.PasteId, Version: 3}, nil
+}
+
+func (f *fakePastes) Delete(_ context.Context, _ *dtos.PastesFilterDto, version *int) (bool, error) {
+	return version == nil || *version == 3, nil
+}
+
+type fakeAudit struct {
+	repositories.AuditRepository
+}
+
+func (f *fakeAudit) Record(context.Context, enums.AuditEntity, int, enums.AuditAction, int) error {
+	return nil
+}
+
+func TestIfMatch(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	uow := &fakeUnitOfWork{repositories: &repositories.Repositories{Pastes: &fakePastes{}, Audit: &fakeAudit{}}}
+	controller := NewPasteController(services.NewPasteService(uow, &fakePastes{}, nil, logger), logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middlewares.NewErrorHandler(logger)})
+	app.Delete("/api/pastes/:id<int>", controller.DeletePaste)
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{name: "no header", header: "", want: fiber.StatusNoContent},
+		{name: "current version", header: `"3"`, want: fiber.StatusNoContent},
+		{name: "any version", header: "*", want: fiber.StatusNoContent},
+		{name: "other version", header: `"2"`, want: fiber.StatusPreconditionFailed},
+		{name: "weak tag of the current version", header: `W/"3"`, want: fiber.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodDelete, "/api/pastes/1", nil)
+			if tt.header != "" {
+				req.Header.Set(fiber.HeaderIfMatch, tt.header)
+			}
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
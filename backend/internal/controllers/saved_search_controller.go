@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"api/internal/dtos"
+	"api/internal/responses"
+	"api/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SavedSearchController interface {
+	Find(c *fiber.Ctx) error
+	Create(c *fiber.Ctx) error
+	Update(c *fiber.Ctx) error
+	Delete(c *fiber.Ctx) error
+	Execute(c *fiber.Ctx) error
+}
+
+type savedSearchController struct {
+	savedSearchService services.SavedSearchService
+}
+
+func NewSavedSearchController(s services.SavedSearchService) SavedSearchController {
+	return &savedSearchController{savedSearchService: s}
+}
+
+func (s *savedSearchController) Find(c *fiber.Ctx) error {
+	queryObj, err := parseQuery[dtos.SavedSearchFilterDto](c)
+	if err != nil {
+		return err
+	}
+
+	searches, err := s.savedSearchService.Find(c.UserContext(), queryObj)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(searches)
+}
+
+func (s *savedSearchController) Create(c *fiber.Ctx) error {
+	var body dtos.SavedSearchDto
+
+	if err := parseBody(c, &body); err != nil {
+		return err
+	}
+
+	newSearch, err := s.savedSearchService.Create(c.UserContext(), &body)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(newSearch)
+}
+
+func (s *savedSearchController) Update(c *fiber.Ctx) error {
+	var body dtos.UpdateSavedSearchDto
+
+	id, err := savedSearchId(c)
+	if err != nil {
+		return err
+	}
+
+	if err := parseBody(c, &body); err != nil {
+		return err
+	}
+
+	updated, err := s.savedSearchService.Update(c.UserContext(), id, &body)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(updated)
+}
+
+func (s *savedSearchController) Delete(c *fiber.Ctx) error {
+	id, err := savedSearchId(c)
+	if err != nil {
+		return err
+	}
+
+	if err := s.savedSearchService.Delete(c.UserContext(), id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Execute runs a saved search; `pagination[...]` in the url overrides the
+// stored pagination.
+func (s *savedSearchController) Execute(c *fiber.Ctx) error {
+	target, err := parseQuery[dtos.SavedSearchFilterDto](c)
+	if err != nil {
+		return err
+	}
+
+	overrides, err := parseQuery[dtos.PastesSearchQueryDto](c)
+	if err != nil {
+		return err
+	}
+
+	shape, err := parseQuery[dtos.ResponseShapeDto](c)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.savedSearchService.Execute(c.UserContext(), target, overrides.Pagination, shape)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+// savedSearchId reads the `:id` route parameter.
+func savedSearchId(c *fiber.Ctx) (int, error) {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return 0, responses.NewBadRequestError("Invalid saved search id")
+	}
+
+	return id, nil
+}
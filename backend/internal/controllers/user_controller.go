@@ -1,16 +1,23 @@
 package controllers
 
 import (
+	"api/internal/dtos"
+	"api/internal/models"
+	"api/internal/responses"
 	"api/internal/services"
+	"api/internal/services/etag"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type UserController interface {
 	Find(c *fiber.Ctx) error
+	FindOne(c *fiber.Ctx) error
 	Create(c *fiber.Ctx) error
 	Update(c *fiber.Ctx) error
+	Patch(c *fiber.Ctx) error
 	Delete(c *fiber.Ctx) error
+	Suggest(c *fiber.Ctx) error
 }
 
 type userController struct {
@@ -22,17 +29,136 @@ func NewUserController(s services.UserService) UserController {
 }
 
 func (u *userController) Find(c *fiber.Ctx) error {
-	return u.userService.Find(c)
+	queryObj, err := parseQuery[dtos.UserFiltersDto](c)
+	if err != nil {
+		return err
+	}
+
+	result, err := u.userService.Find(c.UserContext(), queryObj)
+	if err != nil {
+		return err
+	}
+
+	return u.sendUser(c, result)
+}
+
+func (u *userController) FindOne(c *fiber.Ctx) error {
+	target, err := userTarget(c)
+	if err != nil {
+		return err
+	}
+
+	result, err := u.userService.FindOne(c.UserContext(), target)
+	if err != nil {
+		return err
+	}
+
+	return u.sendUser(c, result)
 }
 
 func (u *userController) Create(c *fiber.Ctx) error {
-	return u.userService.Create(c)
+	var body dtos.UserDto
+
+	if err := parseBody(c, &body); err != nil {
+		return err
+	}
+
+	newUsr, err := u.userService.Create(c.UserContext(), &body)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderETag, etag.Format(newUsr.Version))
+
+	return c.Status(fiber.StatusOK).JSON(newUsr)
 }
 
 func (u *userController) Update(c *fiber.Ctx) error {
-	return u.userService.Update(c)
+	var body dtos.UpdateUserDto
+
+	target, err := userTarget(c)
+	if err != nil {
+		return err
+	}
+
+	if err := parseBody(c, &body); err != nil {
+		return err
+	}
+
+	newUsr, err := u.userService.Update(c.UserContext(), target, &body, ifMatch(c))
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderETag, etag.Format(newUsr.Version))
+
+	return c.Status(fiber.StatusOK).JSON(newUsr)
+}
+
+func (u *userController) Patch(c *fiber.Ctx) error {
+	target, err := userTarget(c)
+	if err != nil {
+		return err
+	}
+
+	newUsr, err := u.userService.Patch(c.UserContext(), target, c.Body(), c.Get(fiber.HeaderContentType), ifMatch(c))
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderETag, etag.Format(newUsr.Version))
+
+	return c.Status(fiber.StatusOK).JSON(newUsr)
 }
 
 func (u *userController) Delete(c *fiber.Ctx) error {
-	return u.userService.Delete(c)
+	target, err := userTarget(c)
+	if err != nil {
+		return err
+	}
+
+	if err := u.userService.Delete(c.UserContext(), target, ifMatch(c)); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (u *userController) Suggest(c *fiber.Ctx) error {
+	queryObj, err := parseQuery[dtos.SuggestQueryDto](c)
+	if err != nil {
+		return err
+	}
+
+	suggestions, err := u.userService.Suggest(c.UserContext(), queryObj)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(suggestions)
+}
+
+func (u *userController) sendUser(c *fiber.Ctx, usr *models.UserModel) error {
+	c.Set(fiber.HeaderETag, etag.Format(usr.Version))
+
+	if notModified(c, usr.Version) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(usr)
+}
+
+// userTarget builds a filter matching exactly one user, either by the `:id`
+// or by the `:socialId` route parameter.
+func userTarget(c *fiber.Ctx) (*dtos.UserFiltersDto, error) {
+	if socialId := c.Params("socialId"); socialId != "" {
+		return &dtos.UserFiltersDto{SocialId: &socialId}, nil
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return nil, responses.NewBadRequestError("Invalid user id")
+	}
+
+	return &dtos.UserFiltersDto{Id: &id}, nil
 }
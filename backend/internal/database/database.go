@@ -1,25 +1,88 @@
 package database
 
 import (
+	"api/internal/config"
 	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type PostgresDatabase interface {
-	Connect(dsn string) *pgxpool.Pool
+	Connect(dsn string) (*pgxpool.Pool, error)
 }
 
-type postgresDatabase struct{}
+type postgresDatabase struct {
+	config config.DatabaseConfig
+}
+
+// Connect creates the pool. Connections are opened lazily, so a database
+// that is down only shows up on the first query.
+func (d *postgresDatabase) Connect(dsn string) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid db connection string: %w", err)
+	}
+
+	// Postgres cancels any statement running longer than this on its own, even
+	// if nobody is waiting for the result anymore.
+	if d.config.StatementTimeout > 0 {
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(d.config.StatementTimeout.Milliseconds(), 10)
+	}
+
+	poolConfig.ConnConfig.Tracer = queryTracer{}
+
+	poolConfig.MaxConns = d.config.MaxConns
+	poolConfig.MinConns = d.config.MinConns
 
-func (d *postgresDatabase) Connect(dsn string) *pgxpool.Pool {
-	pool, err := pgxpool.New(context.Background(), dsn)
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
-		panic("Cannot connect to db")
+		return nil, fmt.Errorf("cannot create db pool: %w", err)
 	}
-	return pool
+	return pool, nil
+}
+
+// NewPostgresDatabase connects with the given pool sizes and statement_timeout;
+// a zero timeout keeps the server default.
+func NewPostgresDatabase(config config.DatabaseConfig) PostgresDatabase {
+	return &postgresDatabase{config: config}
 }
 
-func NewPostgresDatabase() PostgresDatabase {
-	return &postgresDatabase{}
+const (
+	waitBackoff    = 200 * time.Millisecond
+	waitMaxBackoff = 5 * time.Second
+)
+
+// Wait pings the database until it answers, backing off exponentially between
+// attempts, so the backend can start before Postgres is up. It gives up after
+// timeout.
+func Wait(ctx context.Context, pool *pgxpool.Pool, timeout time.Duration, logger *slog.Logger) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	backoff := waitBackoff
+
+	for {
+		err := pool.Ping(ctx)
+		if err == nil {
+			return nil
+		}
+
+		if ctx.Err() != nil {
+			return fmt.Errorf("db is not available after %s: %w", timeout, err)
+		}
+
+		logger.WarnContext(ctx, "Waiting for db", "retryIn", backoff, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("db is not available after %s: %w", timeout, err)
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, waitMaxBackoff)
+	}
 }
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"api/migrations"
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+// Migrator applies the migrations embedded into the binary. Every command
+// holds a Postgres advisory lock, so replicas migrating on start run one
+// after another and the later ones find nothing to do.
+type Migrator interface {
+	Up(ctx context.Context) ([]*goose.MigrationResult, error)
+	Down(ctx context.Context) (*goose.MigrationResult, error)
+	Redo(ctx context.Context) ([]*goose.MigrationResult, error)
+	Status(ctx context.Context) ([]*goose.MigrationStatus, error)
+	// Versions returns the applied version and the latest embedded one.
+	Versions(ctx context.Context) (current int64, target int64, err error)
+	Close() error
+}
+
+type migrator struct {
+	db       *sql.DB
+	provider *goose.Provider
+}
+
+// NewMigrator opens its own connection rather than borrowing one from the
+// pool, so migrations are not cut short by the statement_timeout of requests.
+func NewMigrator(dsn string, table string) (Migrator, error) {
+	connConfig, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid db connection string: %w", err)
+	}
+
+	return newMigrator(stdlib.OpenDB(*connConfig), table)
+}
+
+// NewPoolMigrator borrows connections from the pool. It is meant for reading
+// versions; closing it leaves the pool open.
+func NewPoolMigrator(pool *pgxpool.Pool, table string) (Migrator, error) {
+	return newMigrator(stdlib.OpenDBFromPool(pool), table)
+}
+
+func newMigrator(db *sql.DB, table string) (Migrator, error) {
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	provider, err := goose.NewProvider(goose.DialectPostgres, db, migrations.FS,
+		goose.WithTableName(table),
+		goose.WithSessionLocker(locker),
+	)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &migrator{db: db, provider: provider}, nil
+}
+
+func (m *migrator) Up(ctx context.Context) ([]*goose.MigrationResult, error) {
+	return m.provider.Up(ctx)
+}
+
+// Down rolls back the last applied migration.
+func (m *migrator) Down(ctx context.Context) (*goose.MigrationResult, error) {
+	return m.provider.Down(ctx)
+}
+
+// Redo rolls back the last applied migration and applies it again.
+func (m *migrator) Redo(ctx context.Context) ([]*goose.MigrationResult, error) {
+	down, err := m.provider.Down(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	up, err := m.provider.UpByOne(ctx)
+	if err != nil {
+		return []*goose.MigrationResult{down}, err
+	}
+
+	return []*goose.MigrationResult{down, up}, nil
+}
+
+func (m *migrator) Status(ctx context.Context) ([]*goose.MigrationStatus, error) {
+	return m.provider.Status(ctx)
+}
+
+func (m *migrator) Versions(ctx context.Context) (int64, int64, error) {
+	return m.provider.GetVersions(ctx)
+}
+
+func (m *migrator) Close() error {
+	return m.db.Close()
+}
@@ -0,0 +1,136 @@
+package domain
+
+import "errors"
+
+// The errors below are returned by services and repositories instead of HTTP
+// responses; the transport decides how to present them.
+
+type NotFoundError struct {
+	Message string
+}
+
+func NewNotFoundError(message string) *NotFoundError {
+	return &NotFoundError{Message: message}
+}
+
+func (e *NotFoundError) Error() string {
+	return e.Message
+}
+
+// ConflictError reports a clash with the stored state. Fields lists the
+// unique fields whose value is already taken, if that is the reason.
+type ConflictError struct {
+	Message string
+	Fields  []string
+}
+
+func NewConflictError(message string, fields ...string) *ConflictError {
+	return &ConflictError{Message: message, Fields: fields}
+}
+
+func (e *ConflictError) Error() string {
+	return e.Message
+}
+
+type Violation struct {
+	Message      string `json:"message"`
+	PropertyPath string `json:"propertyPath"`
+}
+
+type ValidationError struct {
+	Message    string
+	Violations []Violation
+}
+
+func NewValidationError(message string, violations ...Violation) *ValidationError {
+	return &ValidationError{Message: message, Violations: violations}
+}
+
+func NewViolation(message string, propertyPath string) Violation {
+	return Violation{Message: message, PropertyPath: propertyPath}
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+type ForbiddenError struct {
+	Message string
+}
+
+func NewForbiddenError(message string) *ForbiddenError {
+	return &ForbiddenError{Message: message}
+}
+
+func (e *ForbiddenError) Error() string {
+	return e.Message
+}
+
+// PreconditionFailedError means the entity changed since the version the
+// caller based its write on.
+type PreconditionFailedError struct {
+	Message string
+}
+
+func NewPreconditionFailedError(message string) *PreconditionFailedError {
+	return &PreconditionFailedError{Message: message}
+}
+
+func (e *PreconditionFailedError) Error() string {
+	return e.Message
+}
+
+// InvalidInputError is input that cannot be understood at all, as opposed to
+// a well-formed value failing validation.
+type InvalidInputError struct {
+	Message string
+}
+
+func NewInvalidInputError(message string) *InvalidInputError {
+	return &InvalidInputError{Message: message}
+}
+
+func (e *InvalidInputError) Error() string {
+	return e.Message
+}
+
+// FailedDependencyError marks an operation that did not take effect because
+// another one of the same batch failed.
+type FailedDependencyError struct {
+	Message string
+}
+
+func (e *FailedDependencyError) Error() string {
+	return e.Message
+}
+
+var (
+	ErrRolledBack  = &FailedDependencyError{Message: "Rolled back"}
+	ErrNotExecuted = &FailedDependencyError{Message: "Not executed"}
+)
+
+var (
+	// ErrUnsupportedMediaType is a body in a media type the operation does
+	// not take, like a patch that is neither a merge patch nor a JSON Patch.
+	ErrUnsupportedMediaType = errors.New("unsupported patch media type")
+	// ErrInvalidPatch is a patch document that cannot be parsed or applied.
+	ErrInvalidPatch = errors.New("invalid patch document")
+)
+
+// IsClientError reports whether err is one of the errors above, i.e. caused
+// by the input rather than by the server.
+func IsClientError(err error) bool {
+	var (
+		notFound     *NotFoundError
+		conflict     *ConflictError
+		validation   *ValidationError
+		forbidden    *ForbiddenError
+		precondition *PreconditionFailedError
+		invalid      *InvalidInputError
+		dependency   *FailedDependencyError
+	)
+
+	return errors.As(err, &notFound) || errors.As(err, &conflict) || errors.As(err, &validation) ||
+		errors.As(err, &forbidden) || errors.As(err, &precondition) || errors.As(err, &invalid) ||
+		errors.As(err, &dependency)
+}
@@ -0,0 +1,21 @@
+package dtos
+
+import (
+	"api/internal/enums"
+	"encoding/json"
+)
+
+type BatchPastesDto struct {
+	Mode       *enums.BatchMode         `json:"mode" validate:"omitempty,oneof=atomic bestEffort"`
+	Operations []BatchPasteOperationDto `json:"operations" validate:"required,min=1,max=100,dive"`
+}
+
+// BatchPasteOperationDto is a single operation of a batch. Data holds a
+// PasteDto for `create` and an UpdatePasteDto for `update`; Version works
+// like the If-Match header of the single-item endpoints.
+type BatchPasteOperationDto struct {
+	Op      enums.BatchOperation `json:"op" validate:"required,oneof=create update delete"`
+	Id      *int                 `json:"id" validate:"required_unless=Op create,omitempty,min=1"`
+	Version *int                 `json:"version" validate:"omitempty,min=1"`
+	Data    json.RawMessage      `json:"data" validate:"required_unless=Op delete"`
+}
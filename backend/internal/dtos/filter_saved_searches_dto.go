@@ -0,0 +1,7 @@
+package dtos
+
+type SavedSearchFilterDto struct {
+	UserId   *int    `json:"userId" validate:"omitempty,min=1"`
+	SocialId *string `json:"socialId" validate:"omitempty,min=1,max=255"`
+	Name     *string `json:"name" validate:"omitempty,min=1,max=64"`
+}
@@ -1,5 +1,7 @@
 package dtos
 
+import "log/slog"
+
 type PasteDto struct {
 	Title  string `json:"title" validate:"required,min=1,max=32"`
 	Paste  string `json:"paste" validate:"required,min=1,max=2096"`
@@ -10,3 +12,25 @@ type UpdatePasteDto struct {
 	Title string `json:"title" validate:"required,min=1,max=32"`
 	Paste string `json:"paste" validate:"required,min=1,max=2096"`
 }
+
+type PatchPasteDto struct {
+	Title *string `json:"title" validate:"omitempty,min=1,max=32"`
+	Paste *string `json:"paste" validate:"omitempty,min=1,max=2096"`
+}
+
+// LogValue leaves the body out of logs, it is user content.
+func (d PasteDto) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("title", d.Title),
+		slog.Int("pasteLength", len(d.Paste)),
+		slog.Int("userId", d.UserId),
+	)
+}
+
+// LogValue leaves the body out of logs, it is user content.
+func (d UpdatePasteDto) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("title", d.Title),
+		slog.Int("pasteLength", len(d.Paste)),
+	)
+}
@@ -0,0 +1,33 @@
+package dtos
+
+import (
+	"api/internal/enums"
+	"log/slog"
+)
+
+type ExportPastesQueryDto struct {
+	Filter *PastesFilterDto      `json:"filter" validate:"omitempty"`
+	Format *enums.TransferFormat `json:"format" validate:"omitempty,oneof=json ndjson csv"`
+}
+
+type ImportPastesQueryDto struct {
+	Format     *enums.TransferFormat   `json:"format" validate:"omitempty,oneof=json ndjson csv"`
+	OnConflict *enums.ConflictStrategy `json:"onConflict" validate:"omitempty,oneof=skip overwrite rename"`
+}
+
+// ImportPasteDto is a single imported record. The author is referenced by
+// socialId, so exports of one server can be imported into another.
+type ImportPasteDto struct {
+	Title    string `json:"title" validate:"required,min=1,max=32"`
+	Paste    string `json:"paste" validate:"required,min=1,max=2096"`
+	SocialId string `json:"socialId" validate:"required"`
+}
+
+// LogValue leaves the body out of logs, it is user content.
+func (d ImportPasteDto) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("title", d.Title),
+		slog.Int("pasteLength", len(d.Paste)),
+		slog.String("socialId", d.SocialId),
+	)
+}
@@ -3,4 +3,6 @@ package dtos
 type PastesSearchQueryDto struct {
 	Filter     *PastesFilterDto `json:"filter" validate:"omitempty"`
 	Pagination *PaginationDto   `json:"pagination" validate:"omitempty"`
+	Snippet    *SnippetDto      `json:"snippet" validate:"omitempty"`
+	Facets     *string          `json:"facets" validate:"omitempty"`
 }
@@ -0,0 +1,6 @@
+package dtos
+
+type ResponseShapeDto struct {
+	Fields  map[string]string `json:"fields" validate:"omitempty,dive,keys,oneof=pastes users,endkeys"`
+	Include *string           `json:"include" validate:"omitempty"`
+}
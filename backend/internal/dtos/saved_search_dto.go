@@ -0,0 +1,12 @@
+package dtos
+
+type SavedSearchDto struct {
+	UserId int                   `json:"userId" validate:"required,min=1"`
+	Name   string                `json:"name" validate:"required,min=1,max=64"`
+	Query  *PastesSearchQueryDto `json:"query" validate:"required"`
+}
+
+type UpdateSavedSearchDto struct {
+	Name  string                `json:"name" validate:"required,min=1,max=64"`
+	Query *PastesSearchQueryDto `json:"query" validate:"required"`
+}
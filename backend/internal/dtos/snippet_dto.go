@@ -0,0 +1,10 @@
+package dtos
+
+import (
+	"api/internal/enums"
+)
+
+type SnippetDto struct {
+	Length    *int                   `json:"length" validate:"omitempty,min=16,max=512"`
+	Highlight *enums.HighlightMarker `json:"highlight" validate:"omitempty,oneof=discord html none"`
+}
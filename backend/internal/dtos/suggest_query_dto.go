@@ -0,0 +1,8 @@
+package dtos
+
+type SuggestQueryDto struct {
+	Query    *string `json:"q" validate:"omitempty,max=64"`
+	Limit    *int    `json:"limit" validate:"omitempty,min=1,max=25"`
+	UserId   *int    `json:"userId" validate:"omitempty,min=1"`
+	SocialId *string `json:"socialId" validate:"omitempty,min=1,max=255"`
+}
@@ -10,3 +10,8 @@ type UpdateUserDto struct {
 	Username    string `json:"username" validate:"required,min=1,max=32"`
 	DisplayName string `json:"displayName" validate:"required,min=1,max=64"`
 }
+
+type PatchUserDto struct {
+	Username    *string `json:"username" validate:"omitempty,min=1,max=32"`
+	DisplayName *string `json:"displayName" validate:"omitempty,min=1,max=64"`
+}
@@ -0,0 +1,16 @@
+package enums
+
+type AuditEntity string
+
+const (
+	AuditPaste AuditEntity = "paste"
+	AuditUser  AuditEntity = "user"
+)
+
+type AuditAction string
+
+const (
+	AuditCreate AuditAction = "create"
+	AuditUpdate AuditAction = "update"
+	AuditDelete AuditAction = "delete"
+)
@@ -0,0 +1,8 @@
+package enums
+
+type BatchMode string
+
+const (
+	BatchAtomic     BatchMode = "atomic"
+	BatchBestEffort BatchMode = "bestEffort"
+)
@@ -0,0 +1,9 @@
+package enums
+
+type BatchOperation string
+
+const (
+	BatchCreate BatchOperation = "create"
+	BatchUpdate BatchOperation = "update"
+	BatchDelete BatchOperation = "delete"
+)
@@ -1,5 +1,10 @@
 package enums
 
 const (
-	DbCodeDuplicateKey = "23505"
+	DbCodeDuplicateKey  = "23505"
+	DbCodeQueryCanceled = "57014"
+	// Serialization failures and deadlocks abort a transaction that would
+	// succeed if simply run again.
+	DbCodeSerializationFailure = "40001"
+	DbCodeDeadlockDetected     = "40P01"
 )
@@ -0,0 +1,24 @@
+package enums
+
+// ErrorCode is the stable, machine readable `code` of an error response.
+// Clients should switch on it instead of on messages.
+type ErrorCode string
+
+const (
+	ErrorBadRequest           ErrorCode = "bad_request"
+	ErrorUnauthorized         ErrorCode = "unauthorized"
+	ErrorForbidden            ErrorCode = "forbidden"
+	ErrorNotFound             ErrorCode = "not_found"
+	ErrorMethodNotAllowed     ErrorCode = "method_not_allowed"
+	ErrorConflict             ErrorCode = "conflict"
+	ErrorPreconditionFailed   ErrorCode = "precondition_failed"
+	ErrorPayloadTooLarge      ErrorCode = "payload_too_large"
+	ErrorUnsupportedMediaType ErrorCode = "unsupported_media_type"
+	ErrorValidationFailed     ErrorCode = "validation_failed"
+	ErrorAlreadyExists        ErrorCode = "already_exists"
+	ErrorFailedDependency     ErrorCode = "failed_dependency"
+	ErrorTooManyRequests      ErrorCode = "too_many_requests"
+	ErrorInternal             ErrorCode = "internal"
+	ErrorUnavailable          ErrorCode = "unavailable"
+	ErrorTimeout              ErrorCode = "timeout"
+)
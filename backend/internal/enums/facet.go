@@ -0,0 +1,12 @@
+package enums
+
+type Facet string
+
+const (
+	FacetAuthor Facet = "author"
+	FacetTag    Facet = "tag"
+	FacetMonth  Facet = "month"
+	FacetLength Facet = "length"
+)
+
+var Facets = []Facet{FacetAuthor, FacetTag, FacetMonth, FacetLength}
@@ -0,0 +1,9 @@
+package enums
+
+type HighlightMarker string
+
+const (
+	HighlightDiscord HighlightMarker = "discord"
+	HighlightHtml    HighlightMarker = "html"
+	HighlightNone    HighlightMarker = "none"
+)
@@ -0,0 +1,10 @@
+package enums
+
+const (
+	ResourcePastes = "pastes"
+	ResourceUsers  = "users"
+)
+
+const (
+	IncludeAuthor = "author"
+)
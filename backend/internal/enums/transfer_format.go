@@ -0,0 +1,9 @@
+package enums
+
+type TransferFormat string
+
+const (
+	TransferJson   TransferFormat = "json"
+	TransferNdjson TransferFormat = "ndjson"
+	TransferCsv    TransferFormat = "csv"
+)
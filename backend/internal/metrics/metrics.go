@@ -0,0 +1,63 @@
+// Package metrics holds the Prometheus collectors of the backend. They are
+// registered on Registry, which /metrics serves.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+var Registry = prometheus.NewRegistry()
+
+var (
+	HttpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by method, route template and status.",
+	}, []string{"method", "route", "status"})
+
+	HttpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method, route template and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	QueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Database query latency by repository and method.",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"repository", "method"})
+
+	PastesCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "pastes_created_total",
+		Help: "Pastes created, including batches and imports.",
+	})
+
+	PastesDeleted = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "pastes_deleted_total",
+		Help: "Pastes deleted, including batches.",
+	})
+
+	PasteSearches = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "paste_searches_total",
+		Help: "Paste searches.",
+	})
+
+	PasteSearchesEmpty = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "paste_searches_empty_total",
+		Help: "Paste searches that found nothing.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HttpRequests,
+		HttpRequestDuration,
+		QueryDuration,
+		PastesCreated,
+		PastesDeleted,
+		PasteSearches,
+		PasteSearchesEmpty,
+	)
+}
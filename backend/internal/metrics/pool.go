@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	poolAcquiredConns = prometheus.NewDesc("db_pool_acquired_connections", "Connections currently in use.", nil, nil)
+	poolIdleConns     = prometheus.NewDesc("db_pool_idle_connections", "Connections currently idle.", nil, nil)
+	poolTotalConns    = prometheus.NewDesc("db_pool_total_connections", "Connections open, including ones being established.", nil, nil)
+	poolMaxConns      = prometheus.NewDesc("db_pool_max_connections", "Largest size the pool may grow to.", nil, nil)
+	poolAcquires      = prometheus.NewDesc("db_pool_acquires_total", "Connections acquired from the pool.", nil, nil)
+	poolEmptyAcquires = prometheus.NewDesc("db_pool_empty_acquires_total", "Acquires that had to wait because no connection was idle.", nil, nil)
+	poolAcquireWait   = prometheus.NewDesc("db_pool_acquire_wait_seconds_total", "Time spent waiting for a connection.", nil, nil)
+)
+
+// poolCollector reads the stats of the pool on every scrape.
+type poolCollector struct {
+	pool *pgxpool.Pool
+}
+
+func NewPoolCollector(pool *pgxpool.Pool) prometheus.Collector {
+	return &poolCollector{pool: pool}
+}
+
+func (p *poolCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- poolAcquiredConns
+	descs <- poolIdleConns
+	descs <- poolTotalConns
+	descs <- poolMaxConns
+	descs <- poolAcquires
+	descs <- poolEmptyAcquires
+	descs <- poolAcquireWait
+}
+
+func (p *poolCollector) Collect(metrics chan<- prometheus.Metric) {
+	stat := p.pool.Stat()
+
+	metrics <- prometheus.MustNewConstMetric(poolAcquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	metrics <- prometheus.MustNewConstMetric(poolIdleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	metrics <- prometheus.MustNewConstMetric(poolTotalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	metrics <- prometheus.MustNewConstMetric(poolMaxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	metrics <- prometheus.MustNewConstMetric(poolAcquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+	metrics <- prometheus.MustNewConstMetric(poolEmptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	metrics <- prometheus.MustNewConstMetric(poolAcquireWait, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+}
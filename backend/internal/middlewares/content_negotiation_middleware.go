@@ -0,0 +1,240 @@
+package middlewares
+
+import (
+	"api/internal/responses"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime"
+	"slices"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	MIMEApplicationMsgpack = "application/msgpack"
+	MIMEApplicationYaml    = "application/yaml"
+)
+
+var (
+	msgpackMediaTypes = []string{MIMEApplicationMsgpack, "application/x-msgpack", "application/vnd.msgpack"}
+	yamlMediaTypes    = []string{MIMEApplicationYaml, "application/x-yaml", "text/yaml"}
+)
+
+// NewContentNegotiation lets clients speak MessagePack or YAML instead of JSON.
+// Request bodies are translated to JSON by their Content-Type before the
+// handlers see them, and JSON responses, errors included, are re-encoded to
+// the best match of the Accept header. JSON stays the default.
+func NewContentNegotiation() fiber.Handler {
+	offers := append(append([]string{fiber.MIMEApplicationJSON}, msgpackMediaTypes...), yamlMediaTypes...)
+
+	return func(ctx *fiber.Ctx) error {
+		if err := decodeRequestBody(ctx); err != nil {
+			return responses.NewBadRequestError(err.Error())
+		}
+
+		ctx.Vary(fiber.HeaderAccept)
+		accepted := ctx.Accepts(offers...)
+
+		if err := ctx.Next(); err != nil {
+			if err := ctx.App().ErrorHandler(ctx, err); err != nil {
+				return err
+			}
+		}
+
+		return encodeResponseBody(ctx, accepted)
+	}
+}
+
+func decodeRequestBody(ctx *fiber.Ctx) error {
+	mediaType, _, err := mime.ParseMediaType(ctx.Get(fiber.HeaderContentType))
+	if err != nil || len(ctx.Body()) == 0 {
+		return nil
+	}
+
+	var value any
+	switch {
+	case slices.Contains(msgpackMediaTypes, mediaType):
+		err = msgpack.Unmarshal(ctx.Body(), &value)
+	case slices.Contains(yamlMediaTypes, mediaType):
+		err = yaml.Unmarshal(ctx.Body(), &value)
+	default:
+		return nil
+	}
+
+	if err != nil {
+		return errors.New("Cannot parse body...")
+	}
+
+	body, err := json.Marshal(value)
+	if err != nil {
+		return errors.New("Cannot parse body...")
+	}
+
+	ctx.Request().SetBody(body)
+	ctx.Request().Header.SetContentType(fiber.MIMEApplicationJSON)
+
+	return nil
+}
+
+func encodeResponseBody(ctx *fiber.Ctx, accepted string) error {
+	response := ctx.Response()
+	isMsgpack := slices.Contains(msgpackMediaTypes, accepted)
+	isYaml := slices.Contains(yamlMediaTypes, accepted)
+
+	if (!isMsgpack && !isYaml) || response.IsBodyStream() || len(response.Body()) == 0 {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(string(response.Header.ContentType()))
+	if err != nil || (mediaType != fiber.MIMEApplicationJSON && mediaType != responses.MIMEApplicationProblemJSON) {
+		return nil
+	}
+
+	value, err := decodeOrdered(json.NewDecoder(bytes.NewReader(response.Body())))
+	if err != nil {
+		return err
+	}
+
+	var body []byte
+	if isMsgpack {
+		var buffer bytes.Buffer
+		err = encodeMsgpack(msgpack.NewEncoder(&buffer), value)
+		body = buffer.Bytes()
+		ctx.Set(fiber.HeaderContentType, MIMEApplicationMsgpack)
+	} else {
+		var buffer bytes.Buffer
+		encoder := yaml.NewEncoder(&buffer)
+		encoder.SetIndent(2)
+		err = encoder.Encode(yamlNode(value))
+		body = buffer.Bytes()
+		ctx.Set(fiber.HeaderContentType, MIMEApplicationYaml+"; charset=utf-8")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	response.SetBodyRaw(body)
+	return nil
+}
+
+// orderedObject keeps the keys of a JSON object in the order the handler wrote
+// them, which a map would lose.
+type orderedObject []orderedField
+
+type orderedField struct {
+	key   string
+	value any
+}
+
+func decodeOrdered(decoder *json.Decoder) (any, error) {
+	decoder.UseNumber()
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		object := orderedObject{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object = append(object, orderedField{key: key.(string), value: value})
+		}
+		_, err = decoder.Token()
+		return object, err
+	case json.Delim('['):
+		array := []any{}
+		for decoder.More() {
+			value, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err = decoder.Token()
+		return array, err
+	default:
+		return token, nil
+	}
+}
+
+func encodeMsgpack(encoder *msgpack.Encoder, value any) error {
+	switch value := value.(type) {
+	case orderedObject:
+		if err := encoder.EncodeMapLen(len(value)); err != nil {
+			return err
+		}
+		for _, field := range value {
+			if err := encoder.EncodeString(field.key); err != nil {
+				return err
+			}
+			if err := encodeMsgpack(encoder, field.value); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []any:
+		if err := encoder.EncodeArrayLen(len(value)); err != nil {
+			return err
+		}
+		for _, item := range value {
+			if err := encodeMsgpack(encoder, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case json.Number:
+		if integer, err := value.Int64(); err == nil {
+			return encoder.EncodeInt(integer)
+		}
+		float, err := value.Float64()
+		if err != nil {
+			return err
+		}
+		return encoder.EncodeFloat64(float)
+	default:
+		return encoder.Encode(value)
+	}
+}
+
+func yamlNode(value any) *yaml.Node {
+	switch value := value.(type) {
+	case orderedObject:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, field := range value {
+			node.Content = append(node.Content, yamlNode(field.key), yamlNode(field.value))
+		}
+		return node
+	case []any:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range value {
+			node.Content = append(node.Content, yamlNode(item))
+		}
+		return node
+	case json.Number:
+		tag := "!!float"
+		if _, err := value.Int64(); err == nil {
+			tag = "!!int"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value.String()}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(value)}
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value.(string)}
+	}
+}
@@ -0,0 +1,23 @@
+package middlewares
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NewDeadline bounds the request by timeout. Handlers pass ctx.UserContext()
+// down to the repositories, so queries still running when it expires are
+// cancelled and the request ends with a 504. A shorter deadline set earlier
+// in the chain wins.
+func NewDeadline(timeout time.Duration) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		deadline, cancel := context.WithTimeout(ctx.UserContext(), timeout)
+		defer cancel()
+
+		ctx.SetUserContext(deadline)
+
+		return ctx.Next()
+	}
+}
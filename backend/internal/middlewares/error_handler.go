@@ -0,0 +1,73 @@
+package middlewares
+
+import (
+	"api/internal/enums"
+	"api/internal/logging"
+	"api/internal/responses"
+	"errors"
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// fiberErrorCodes maps the statuses of errors raised by Fiber itself, such as
+// unknown routes or oversized bodies, to problem codes.
+var fiberErrorCodes = map[int]enums.ErrorCode{
+	fiber.StatusBadRequest:            enums.ErrorBadRequest,
+	fiber.StatusUnauthorized:          enums.ErrorUnauthorized,
+	fiber.StatusForbidden:             enums.ErrorForbidden,
+	fiber.StatusNotFound:              enums.ErrorNotFound,
+	fiber.StatusMethodNotAllowed:      enums.ErrorMethodNotAllowed,
+	fiber.StatusConflict:              enums.ErrorConflict,
+	fiber.StatusPreconditionFailed:    enums.ErrorPreconditionFailed,
+	fiber.StatusRequestEntityTooLarge: enums.ErrorPayloadTooLarge,
+	fiber.StatusUnsupportedMediaType:  enums.ErrorUnsupportedMediaType,
+	fiber.StatusUnprocessableEntity:   enums.ErrorValidationFailed,
+	fiber.StatusTooManyRequests:       enums.ErrorTooManyRequests,
+	fiber.StatusServiceUnavailable:    enums.ErrorUnavailable,
+}
+
+// NewErrorHandler renders every error returned by a handler as an RFC 7807
+// `application/problem+json` body carrying the id of the request. Domain
+// errors are mapped to their status; anything unknown is logged and answered
+// with a generic 500.
+func NewErrorHandler(logger *slog.Logger) fiber.ErrorHandler {
+	return func(ctx *fiber.Ctx, err error) error {
+		problem := toProblem(err)
+
+		switch {
+		case problem.Status == fiber.StatusGatewayTimeout:
+			logger.WarnContext(ctx.UserContext(), "Request timed out", "error", err)
+		case problem.Status >= fiber.StatusInternalServerError:
+			logger.ErrorContext(ctx.UserContext(), "Request failed", "error", err)
+		}
+
+		if problem.Instance == "" {
+			problem.Instance = ctx.OriginalURL()
+		}
+		problem.RequestId = logging.RequestId(ctx.UserContext())
+
+		ctx.Status(problem.Status)
+		if err := ctx.JSON(problem); err != nil {
+			return err
+		}
+		ctx.Set(fiber.HeaderContentType, responses.MIMEApplicationProblemJSON)
+
+		return nil
+	}
+}
+
+func toProblem(err error) *responses.Problem {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		if code, ok := fiberErrorCodes[fiberErr.Code]; ok {
+			return responses.NewProblem(fiberErr.Code, code, fiberErr.Message)
+		}
+		if fiberErr.Code < fiber.StatusInternalServerError {
+			return responses.NewProblem(fiberErr.Code, enums.ErrorBadRequest, fiberErr.Message)
+		}
+	}
+
+	copied := *responses.FromError(err)
+	return &copied
+}
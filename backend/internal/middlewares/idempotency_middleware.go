@@ -0,0 +1,138 @@
+package middlewares
+
+import (
+	"api/internal/repositories"
+	"api/internal/responses"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+	idempotencyKeyMaxLength  = 255
+)
+
+// replayedHeaders are the response headers stored with the body and sent
+// again on a replay.
+var replayedHeaders = []string{fiber.HeaderETag, fiber.HeaderLocation, fiber.HeaderLastModified}
+
+// NewIdempotency makes create requests safe to retry. The first response for an
+// `Idempotency-Key` is stored for ttl and replayed for repeats with the same
+// key and body; reusing the key with a different body yields a 409.
+// Server errors are not stored, so such requests can be retried for real.
+// Stored client errors are replayed as they were, with the requestId of the
+// first request.
+func NewIdempotency(repository repositories.IdempotencyRepository, ttl time.Duration, logger *slog.Logger) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		key := ctx.Get(HeaderIdempotencyKey)
+		if key == "" {
+			return ctx.Next()
+		}
+
+		if len(key) > idempotencyKeyMaxLength {
+			return responses.NewBadRequestError("Idempotency-Key is too long")
+		}
+
+		requestHash := hashRequest(ctx)
+
+		claimed, err := repository.Claim(ctx.UserContext(), key, requestHash, ttl)
+		if err != nil {
+			logger.ErrorContext(ctx.UserContext(), "Cannot claim Idempotency-Key", "error", err)
+			return responses.NewInternalError()
+		}
+
+		if !claimed {
+			return replay(ctx, repository, key, requestHash, logger)
+		}
+
+		if err := ctx.Next(); err != nil {
+			if err := ctx.App().ErrorHandler(ctx, err); err != nil {
+				releaseKey(ctx, repository, key, logger)
+				return err
+			}
+		}
+
+		status := ctx.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError {
+			releaseKey(ctx, repository, key, logger)
+			return nil
+		}
+
+		body := append([]byte(nil), ctx.Response().Body()...)
+		contentType := string(ctx.Response().Header.ContentType())
+
+		headers := map[string]string{}
+		for _, name := range replayedHeaders {
+			// Header values point into the response buffer, which is reused.
+			if value := ctx.GetRespHeader(name); value != "" {
+				headers[name] = strings.Clone(value)
+			}
+		}
+
+		if err := repository.Complete(detached(ctx), key, status, contentType, headers, body); err != nil {
+			logger.ErrorContext(ctx.UserContext(), "Cannot store the response for Idempotency-Key", "error", err)
+		}
+
+		return nil
+	}
+}
+
+func replay(ctx *fiber.Ctx, repository repositories.IdempotencyRepository, key string, requestHash string, logger *slog.Logger) error {
+	record, err := repository.Find(ctx.UserContext(), key)
+	if err != nil {
+		logger.ErrorContext(ctx.UserContext(), "Cannot find Idempotency-Key", "error", err)
+		return responses.NewInternalError()
+	}
+
+	if record == nil {
+		return responses.NewConflictError("Idempotency-Key was released, retry the request")
+	}
+
+	if record.RequestHash != requestHash {
+		return responses.NewConflictError("Idempotency-Key was already used with a different request")
+	}
+
+	if !record.IsCompleted() {
+		return responses.NewConflictError("A request with this Idempotency-Key is still in progress")
+	}
+
+	ctx.Set(HeaderIdempotentReplayed, "true")
+	if record.ContentType != nil {
+		ctx.Set(fiber.HeaderContentType, *record.ContentType)
+	}
+	for name, value := range record.ResponseHeaders {
+		ctx.Set(name, value)
+	}
+
+	return ctx.Status(*record.StatusCode).Send(record.ResponseBody)
+}
+
+func releaseKey(ctx *fiber.Ctx, repository repositories.IdempotencyRepository, key string, logger *slog.Logger) {
+	if err := repository.Release(detached(ctx), key); err != nil {
+		logger.ErrorContext(ctx.UserContext(), "Cannot release Idempotency-Key", "error", err)
+	}
+}
+
+// detached is the context of the request without its deadline: the outcome
+// of a request has to be stored even if the request itself ran out of time.
+func detached(ctx *fiber.Ctx) context.Context {
+	return context.WithoutCancel(ctx.UserContext())
+}
+
+// hashRequest fingerprints the method, path and body of the request.
+func hashRequest(ctx *fiber.Ctx) string {
+	hash := sha256.New()
+	hash.Write([]byte(ctx.Method()))
+	hash.Write([]byte{0})
+	hash.Write([]byte(ctx.Path()))
+	hash.Write([]byte{0})
+	hash.Write(ctx.Body())
+	return hex.EncodeToString(hash.Sum(nil))
+}
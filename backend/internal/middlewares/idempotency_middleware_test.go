@@ -0,0 +1,102 @@
+package middlewares
+
+import (
+	"api/internal/models"
+	"context"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// memoryIdempotency keeps the keys in a map.
+type memoryIdempotency struct {
+	records map[string]*models.IdempotencyKeyModel
+}
+
+func (m *memoryIdempotency) Claim(_ context.Context, key string, requestHash string, _ time.Duration) (bool, error) {
+	if _, ok := m.records[key]; ok {
+		return false, nil
+	}
+	m.records[key] = &models.IdempotencyKeyModel{Key: key, RequestHash: requestHash}
+	return true, nil
+}
+
+func (m *memoryIdempotency) Find(_ context.Context, key string) (*models.IdempotencyKeyModel, error) {
+	return m.records[key], nil
+}
+
+func (m *memoryIdempotency) Complete(_ context.Context, key string, statusCode int, contentType string, headers map[string]string, body []byte) error {
+	record := m.records[key]
+	record.StatusCode, record.ContentType, record.ResponseHeaders, record.ResponseBody = &statusCode, &contentType, headers, body
+	return nil
+}
+
+func (m *memoryIdempotency) Release(_ context.Context, key string) error {
+	delete(m.records, key)
+	return nil
+}
+
+func (m *memoryIdempotency) Purge(context.Context) (int64, error) {
+	return 0, nil
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repository := &memoryIdempotency{records: map[string]*models.IdempotencyKeyModel{}}
+
+	calls := 0
+	app := fiber.New(fiber.Config{ErrorHandler: NewErrorHandler(logger)})
+	app.Post("/api/pastes", NewIdempotency(repository, time.Hour, logger), func(c *fiber.Ctx) error {
+		calls++
+		c.Set(fiber.HeaderETag, `"1"`)
+		c.Set(fiber.HeaderLocation, "/api/pastes/1")
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"id": 1})
+	})
+
+	send := func() (int, string, map[string]string) {
+		req := httptest.NewRequest(fiber.MethodPost, "/api/pastes", strings.NewReader(`{"title":"a"}`))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		req.Header.Set(HeaderIdempotencyKey, "key")
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		headers := map[string]string{}
+		for _, name := range []string{fiber.HeaderETag, fiber.HeaderLocation, fiber.HeaderContentType, HeaderIdempotentReplayed} {
+			headers[name] = resp.Header.Get(name)
+		}
+
+		return resp.StatusCode, string(body), headers
+	}
+
+	firstStatus, firstBody, firstHeaders := send()
+	status, body, headers := send()
+
+	if calls != 1 {
+		t.Errorf("handler ran %d times, want 1", calls)
+	}
+	if status != firstStatus || body != firstBody {
+		t.Errorf("replay = %d %s, want %d %s", status, body, firstStatus, firstBody)
+	}
+	for _, name := range []string{fiber.HeaderETag, fiber.HeaderLocation, fiber.HeaderContentType} {
+		if headers[name] != firstHeaders[name] {
+			t.Errorf("replayed %s = %q, want %q", name, headers[name], firstHeaders[name])
+		}
+	}
+	if firstHeaders[HeaderIdempotentReplayed] != "" || headers[HeaderIdempotentReplayed] != "true" {
+		t.Errorf("Idempotent-Replayed = %q then %q, want none then true", firstHeaders[HeaderIdempotentReplayed], headers[HeaderIdempotentReplayed])
+	}
+}
@@ -0,0 +1,48 @@
+package middlewares
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NewLogger logs a line per request, with its headers on the debug level.
+// Errors are handed to the error handler right here, like the metrics do, to
+// know the status they end with. Requests skip tells true for are not logged.
+func NewLogger(logger *slog.Logger, skip func(ctx *fiber.Ctx) bool) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if skip(ctx) {
+			return ctx.Next()
+		}
+
+		started := time.Now()
+
+		if err := ctx.Next(); err != nil {
+			if err := ctx.App().ErrorHandler(ctx, err); err != nil {
+				_ = ctx.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", ctx.Method()),
+			slog.String("path", ctx.Path()),
+			slog.Int("status", ctx.Response().StatusCode()),
+			slog.Float64("latencyMs", float64(time.Since(started).Microseconds())/1000),
+			slog.String("ip", ctx.IP()),
+		}
+
+		// Authorization is among them and is redacted by the logger.
+		if logger.Enabled(ctx.UserContext(), slog.LevelDebug) {
+			headers := []any{}
+			for name, values := range ctx.GetReqHeaders() {
+				headers = append(headers, slog.Any(name, values))
+			}
+			attrs = append(attrs, slog.Group("headers", headers...))
+		}
+
+		logger.LogAttrs(ctx.UserContext(), slog.LevelInfo, "Request", attrs...)
+
+		return nil
+	}
+}
@@ -0,0 +1,33 @@
+package middlewares
+
+import (
+	"api/internal/metrics"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NewMetrics counts requests and their latency by method, route template and
+// status. Errors are handed to the error handler right here, like the logger
+// does, to know the status they end with.
+func NewMetrics() fiber.Handler {
+	routeTemplate := newRouteTemplate()
+
+	return func(ctx *fiber.Ctx) error {
+		started := time.Now()
+
+		if err := ctx.Next(); err != nil {
+			if err := ctx.App().ErrorHandler(ctx, err); err != nil {
+				_ = ctx.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		labels := []string{ctx.Method(), routeTemplate(ctx), strconv.Itoa(ctx.Response().StatusCode())}
+
+		metrics.HttpRequests.WithLabelValues(labels...).Inc()
+		metrics.HttpRequestDuration.WithLabelValues(labels...).Observe(time.Since(started).Seconds())
+
+		return nil
+	}
+}
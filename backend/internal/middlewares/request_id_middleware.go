@@ -0,0 +1,48 @@
+package middlewares
+
+import (
+	"api/internal/logging"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+const requestIdMaxLength = 128
+
+// NewRequestId gives every request an id, the `X-Request-ID` of the caller
+// if it sent a sane one or a new UUID otherwise. The id is sent back in the
+// same header, attached to every log line of the request and to error
+// responses.
+func NewRequestId() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id := ctx.Get(fiber.HeaderXRequestID)
+		if !isRequestId(id) {
+			id = utils.UUIDv4()
+		}
+
+		// The header is reused by the next request, while the id may be
+		// logged after this one is answered, e.g. by an export.
+		id = utils.CopyString(id)
+
+		ctx.Set(fiber.HeaderXRequestID, id)
+		ctx.SetUserContext(logging.WithRequestId(ctx.UserContext(), id))
+
+		return ctx.Next()
+	}
+}
+
+// isRequestId accepts printable ASCII only, so a caller cannot forge lines
+// of the text log.
+func isRequestId(id string) bool {
+	if id == "" || len(id) > requestIdMaxLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,35 @@
+package middlewares
+
+import (
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// unmatchedRoute stands for requests that never reached a route, either
+// unknown paths or ones stopped by a middleware like a 401, so they do not
+// blow up the number of series or of span names.
+const unmatchedRoute = "unmatched"
+
+// newRouteTemplate returns a func telling the template of the route a request
+// ended up on, like `/api/pastes/:id<int>`, once the request is handled.
+func newRouteTemplate() func(ctx *fiber.Ctx) string {
+	var once sync.Once
+	var routes map[string]bool
+
+	return func(ctx *fiber.Ctx) string {
+		// Every route is registered by the time the first request comes.
+		once.Do(func() {
+			routes = map[string]bool{}
+			for _, route := range ctx.App().GetRoutes(true) {
+				routes[route.Method+" "+route.Path] = true
+			}
+		})
+
+		route := ctx.Route()
+		if !routes[route.Method+" "+route.Path] {
+			return unmatchedRoute
+		}
+		return route.Path
+	}
+}
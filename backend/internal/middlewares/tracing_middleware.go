@@ -0,0 +1,63 @@
+package middlewares
+
+import (
+	"api/internal/tracing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewTracing makes a span of every request, continuing the trace of the
+// caller when it sends `traceparent`. The span goes down to the services and
+// the queries with ctx.UserContext(). Errors are handed to the error handler
+// right here, like the logger does, to know the status they end with.
+// Requests skip tells true for are not traced.
+func NewTracing(skip func(ctx *fiber.Ctx) bool) fiber.Handler {
+	routeTemplate := newRouteTemplate()
+
+	return func(ctx *fiber.Ctx) error {
+		if skip(ctx) {
+			return ctx.Next()
+		}
+
+		parent := otel.GetTextMapPropagator().Extract(ctx.UserContext(), propagation.HeaderCarrier(ctx.GetReqHeaders()))
+
+		// The route is known only once the request is handled, the span is
+		// renamed then.
+		spanCtx, span := tracing.Start(parent, ctx.Method(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(ctx.Method()),
+				// The path is reused by the next request, while the span may be
+				// exported later.
+				semconv.URLPath(utils.CopyString(ctx.Path())),
+			),
+		)
+		defer span.End()
+
+		ctx.SetUserContext(spanCtx)
+
+		if err := ctx.Next(); err != nil {
+			if err := ctx.App().ErrorHandler(ctx, err); err != nil {
+				_ = ctx.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		route := routeTemplate(ctx)
+		status := ctx.Response().StatusCode()
+
+		span.SetName(ctx.Method() + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route), semconv.HTTPResponseStatusCode(status))
+
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, "")
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,6 @@
+package models
+
+type FacetBucket struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
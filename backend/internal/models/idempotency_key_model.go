@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+type IdempotencyKeyModel struct {
+	Key          string  `db:"key" json:"key"`
+	RequestHash  string  `db:"request_hash" json:"requestHash"`
+	StatusCode   *int    `db:"status_code" json:"statusCode"`
+	ContentType  *string `db:"content_type" json:"contentType"`
+	ResponseBody []byte  `db:"response_body" json:"-"`
+	// ResponseHeaders are the headers of the response replayed along with
+	// its body, such as ETag.
+	ResponseHeaders map[string]string `db:"response_headers" json:"-"`
+
+	CreatedAt time.Time `db:"created_at" json:"createdAt"`
+	ExpiresAt time.Time `db:"expires_at" json:"expiresAt"`
+}
+
+// IsCompleted reports whether the response of the first request was stored.
+func (k *IdempotencyKeyModel) IsCompleted() bool {
+	return k.StatusCode != nil
+}
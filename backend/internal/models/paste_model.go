@@ -1,14 +1,29 @@
 package models
 
-import "time"
+import (
+	"log/slog"
+	"time"
+)
 
 type PasteModel struct {
 	Id    int    `db:"id" json:"id" validate:"omitempty"`
 	Title string `db:"title" json:"title" validate:"omitempty"`
 	Paste string `db:"paste" json:"paste" validate:"omitempty"`
 
-	UserId int `db:"user_id" json:"userId" validate:"omitempty"`
+	UserId  int `db:"user_id" json:"userId" validate:"omitempty"`
+	Version int `db:"version" json:"version" validate:"omitempty"`
 
 	CreatedAt time.Time `db:"created_at" json:"createdAt" validate:"omitempty"`
 	UpdatedAt time.Time `db:"updated_at" json:"updatedAt" validate:"omitempty"`
 }
+
+// LogValue leaves the body out of logs, it is user content.
+func (p PasteModel) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("id", p.Id),
+		slog.String("title", p.Title),
+		slog.Int("pasteLength", len(p.Paste)),
+		slog.Int("userId", p.UserId),
+		slog.Int("version", p.Version),
+	)
+}
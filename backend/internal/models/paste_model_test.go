@@ -0,0 +1,31 @@
+package models
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestPasteLogValue(t *testing.T) {
+	const body = "secret body"
+
+	tests := map[string]any{
+		"paste":  PasteModel{Id: 1, Title: "title", Paste: body},
+		"record": &PasteRecordModel{Id: 1, Title: "title", Paste: body},
+	}
+
+	for name, value := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buffer bytes.Buffer
+			slog.New(slog.NewJSONHandler(&buffer, nil)).Info("test", "value", value)
+
+			if strings.Contains(buffer.String(), body) {
+				t.Errorf("log contains the body: %s", buffer.String())
+			}
+			if !strings.Contains(buffer.String(), `"pasteLength":11`) {
+				t.Errorf("log misses the body length: %s", buffer.String())
+			}
+		})
+	}
+}
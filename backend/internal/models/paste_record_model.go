@@ -0,0 +1,28 @@
+package models
+
+import (
+	"log/slog"
+	"time"
+)
+
+// PasteRecordModel is a paste as it is exported, with the author's socialId
+// instead of the server-local user id.
+type PasteRecordModel struct {
+	Id       int    `db:"id" json:"id" validate:"omitempty"`
+	Title    string `db:"title" json:"title" validate:"omitempty"`
+	Paste    string `db:"paste" json:"paste" validate:"omitempty"`
+	SocialId string `db:"social_id" json:"socialId" validate:"omitempty"`
+
+	CreatedAt time.Time `db:"created_at" json:"createdAt" validate:"omitempty"`
+	UpdatedAt time.Time `db:"updated_at" json:"updatedAt" validate:"omitempty"`
+}
+
+// LogValue leaves the body out of logs, it is user content.
+func (p PasteRecordModel) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("id", p.Id),
+		slog.String("title", p.Title),
+		slog.Int("pasteLength", len(p.Paste)),
+		slog.String("socialId", p.SocialId),
+	)
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"api/internal/dtos"
+	"time"
+)
+
+type SavedSearchModel struct {
+	Id     int                       `db:"id" json:"id" validate:"omitempty"`
+	UserId int                       `db:"user_id" json:"userId" validate:"omitempty"`
+	Name   string                    `db:"name" json:"name" validate:"omitempty"`
+	Query  dtos.PastesSearchQueryDto `db:"query" json:"query" validate:"omitempty"`
+
+	CreatedAt time.Time `db:"created_at" json:"createdAt" validate:"omitempty"`
+	UpdatedAt time.Time `db:"updated_at" json:"updatedAt" validate:"omitempty"`
+}
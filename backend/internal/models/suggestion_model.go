@@ -0,0 +1,6 @@
+package models
+
+type SuggestionModel struct {
+	Id    int    `json:"id"`
+	Label string `json:"label"`
+}
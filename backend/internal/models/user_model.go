@@ -7,6 +7,7 @@ type UserModel struct {
 	Username    string `json:"username" validate:"omitempty"`
 	DisplayName string `json:"displayName" validate:"omitempty"`
 	SocialId    string `json:"socialId" validate:"omitempty"`
+	Version     int    `json:"version" validate:"omitempty"`
 
 	CreatedAt time.Time `json:"createdAt" validate:"omitempty"`
 	UpdatedAt time.Time `json:"updatedAt" validate:"omitempty"`
@@ -0,0 +1,300 @@
+package openapi
+
+import (
+	"api/internal/middlewares"
+	"api/internal/responses"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	version = "3.1.0"
+
+	// securityScheme is the name of the token scheme in the document.
+	securityScheme = "token"
+)
+
+// Endpoint describes a route. Bodies are Go values of the types sent over
+// the wire keyed by media type; their schemas are generated.
+type Endpoint struct {
+	Summary     string
+	Description string
+	Tags        []string
+	// Public routes are served without the token.
+	Public bool
+	// Hidden routes are left out of the document and may be missing from the
+	// app, like /metrics, which depends on the config.
+	Hidden bool
+	// Query lists the DTOs the query string is mapped onto.
+	Query []any
+	// Headers are request headers of Headers the route reads.
+	Headers   []string
+	Body      map[string]any
+	Responses map[int]Result
+}
+
+type Result struct {
+	Description string
+	// Headers are response headers of Headers the route sets.
+	Headers []string
+	Content map[string]any
+}
+
+// Json is a body sent as application/json.
+func Json(value any) map[string]any {
+	return map[string]any{fiber.MIMEApplicationJSON: value}
+}
+
+// Headers describes the headers endpoints refer to by name.
+var Headers = map[string]string{
+	fiber.HeaderIfMatch:                  "Version the write expects, as in ETag. Fails with 412 when it is not current",
+	fiber.HeaderIfNoneMatch:              "Versions the client has. Answered with 304 when the current one is among them",
+	fiber.HeaderIfModifiedSince:          "Answered with 304 when the resource has not changed since",
+	middlewares.HeaderIdempotencyKey:     "Repeating the request with the same key and body replays the first response",
+	fiber.HeaderETag:                     "Version of the resource",
+	fiber.HeaderLastModified:             "Time of the last change of the resource",
+	fiber.HeaderContentDisposition:       "Name of the file to save the body to",
+	middlewares.HeaderIdempotentReplayed: "true when the response is a replay of an earlier one",
+}
+
+var pathParam = regexp.MustCompile(`:(\w+)(<(\w+)>)?`)
+
+// Build describes the routes of the app with endpoints, keyed by the method
+// and the path as Fiber registers them: "GET /api/pastes/:id<int>". It
+// returns the document even when routes and endpoints diverge, along with an
+// error listing every difference.
+func Build(info Info, routes []fiber.Route, endpoints map[string]Endpoint) (*Document, error) {
+	schemas := newSchemas()
+	doc := &Document{
+		OpenApi: version,
+		Info:    info,
+		Paths:   map[string]map[string]*Operation{},
+		Components: Components{
+			Schemas: schemas.components,
+			SecuritySchemes: map[string]*SecurityScheme{
+				securityScheme: {
+					Type:        "apiKey",
+					Name:        fiber.HeaderAuthorization,
+					In:          "header",
+					Description: "The secret token of the server",
+				},
+			},
+		},
+		Security: []map[string][]string{{securityScheme: {}}},
+	}
+
+	var errs []error
+	registered := map[string]bool{}
+
+	for _, route := range routes {
+		// Fiber adds a HEAD to every GET.
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+
+		key := route.Method + " " + route.Path
+		if registered[key] {
+			continue
+		}
+		registered[key] = true
+
+		endpoint, ok := endpoints[key]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s is registered but not described", key))
+			continue
+		}
+		if endpoint.Hidden {
+			continue
+		}
+
+		path, params := convertPath(route.Path)
+		operation, err := endpoint.operation(schemas, route.Method, path, params)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*Operation{}
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = operation
+	}
+
+	keys := make([]string, 0, len(endpoints))
+	for key := range endpoints {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !registered[key] && !endpoints[key].Hidden {
+			errs = append(errs, fmt.Errorf("%s is described but not registered", key))
+		}
+	}
+
+	return doc, errors.Join(errs...)
+}
+
+func (e Endpoint) operation(schemas *schemas, method string, path string, params []*Parameter) (*Operation, error) {
+	operation := &Operation{
+		OperationId: operationId(method, path),
+		Summary:     e.Summary,
+		Description: e.Description,
+		Tags:        e.Tags,
+		Parameters:  params,
+		Responses:   map[string]*Response{},
+	}
+
+	if e.Public {
+		// An empty list overrides the token of the document.
+		operation.Security = &[]map[string][]string{}
+	}
+
+	seen := map[string]bool{}
+	for _, dto := range e.Query {
+		for _, param := range schemas.query(reflect.TypeOf(dto)) {
+			if !seen[param.Name] {
+				seen[param.Name] = true
+				operation.Parameters = append(operation.Parameters, param)
+			}
+		}
+	}
+
+	for _, name := range e.Headers {
+		description, ok := Headers[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown header %s", name)
+		}
+		operation.Parameters = append(operation.Parameters, &Parameter{
+			Name:        name,
+			In:          "header",
+			Description: description,
+			Schema:      &Schema{Type: "string"},
+		})
+	}
+
+	if len(e.Body) > 0 {
+		operation.RequestBody = &RequestBody{Required: true, Content: schemas.content(e.Body)}
+	}
+
+	for status, result := range e.Responses {
+		response := &Response{Description: result.Description, Content: schemas.content(result.Content)}
+		if response.Description == "" {
+			response.Description = http.StatusText(status)
+		}
+
+		for _, name := range result.Headers {
+			description, ok := Headers[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown header %s", name)
+			}
+			if response.Headers == nil {
+				response.Headers = map[string]*Header{}
+			}
+			response.Headers[name] = &Header{Description: description, Schema: &Schema{Type: "string"}}
+		}
+
+		operation.Responses[strconv.Itoa(status)] = response
+	}
+
+	problem := schemas.content(map[string]any{responses.MIMEApplicationProblemJSON: responses.Problem{}})
+	if !e.Public {
+		operation.Responses[strconv.Itoa(fiber.StatusUnauthorized)] = &Response{Description: "The token is missing or wrong", Content: problem}
+	}
+	operation.Responses["default"] = &Response{Description: "Problem details (RFC 7807)", Content: problem}
+
+	return operation, nil
+}
+
+// content describes bodies keyed by media type.
+func (s *schemas) content(bodies map[string]any) map[string]*MediaType {
+	if len(bodies) == 0 {
+		return nil
+	}
+
+	content := map[string]*MediaType{}
+	for mediaType, body := range bodies {
+		content[mediaType] = &MediaType{Schema: s.of(reflect.TypeOf(body))}
+	}
+
+	return content
+}
+
+// query describes the fields of a DTO mapped from the query string. Nested
+// structs and maps are written in brackets: `filter[userId]=1`.
+func (s *schemas) query(t reflect.Type) []*Parameter {
+	t = deref(t)
+	var params []*Parameter
+
+	for _, f := range fields(t) {
+		if f.embedded {
+			params = append(params, s.query(f.Type)...)
+			continue
+		}
+
+		schema, required := s.field(t, f.StructField)
+		param := &Parameter{Name: f.name, In: "query", Required: required, Schema: schema}
+
+		if kind := deref(f.Type).Kind(); kind == reflect.Struct || kind == reflect.Map {
+			explode := true
+			param.Style = "deepObject"
+			param.Explode = &explode
+		}
+
+		params = append(params, param)
+	}
+
+	return params
+}
+
+// convertPath turns the parameters of a Fiber path into OpenAPI ones:
+// /pastes/:id<int> becomes /pastes/{id}.
+func convertPath(path string) (string, []*Parameter) {
+	var params []*Parameter
+
+	for _, match := range pathParam.FindAllStringSubmatch(path, -1) {
+		schema := &Schema{Type: "string"}
+		if match[3] == "int" {
+			schema = &Schema{Type: "integer"}
+		}
+		params = append(params, &Parameter{Name: match[1], In: "path", Required: true, Schema: schema})
+	}
+
+	path = pathParam.ReplaceAllString(path, "{$1}")
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+
+	return path, params
+}
+
+// operationId names an operation after its method and path:
+// GET /api/pastes/{id}/raw is getPastesByIdRaw.
+func operationId(method string, path string) string {
+	var id strings.Builder
+	id.WriteString(strings.ToLower(method))
+
+	for _, segment := range strings.Split(strings.TrimPrefix(path, "/api"), "/") {
+		if name, ok := strings.CutPrefix(segment, "{"); ok {
+			id.WriteString("By")
+			segment = strings.TrimSuffix(name, "}")
+		}
+
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return r == '-' || r == '_' }) {
+			runes := []rune(word)
+			runes[0] = unicode.ToUpper(runes[0])
+			id.WriteString(string(runes))
+		}
+	}
+
+	return id.String()
+}
@@ -0,0 +1,333 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is a JSON Schema as OpenAPI 3.1 uses it, limited to what the DTOs
+// need.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	PropertyNames        *Schema            `json:"propertyNames,omitempty"`
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemas turns Go types into schemas. Named structs become components and
+// are referenced, so each of them is described once.
+type schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemas() *schemas {
+	return &schemas{components: map[string]*Schema{}, names: map[reflect.Type]string{}}
+}
+
+// of describes values of t as encoding/json writes them.
+func (s *schemas) of(t reflect.Type) *Schema {
+	t = deref(t)
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + s.component(t)}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: s.of(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.of(t.Elem())}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	}
+
+	// Interfaces hold anything.
+	return &Schema{}
+}
+
+// component registers the schema of a named struct and returns its name.
+func (s *schemas) component(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+
+	// PaginationResponse[interface {}] is just PaginationResponse, and types
+	// unexported in Go are still named like the others.
+	name, _, _ := strings.Cut(t.Name(), "[")
+	name = strings.ToUpper(name[:1]) + name[1:]
+	if _, taken := s.components[name]; taken {
+		pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+
+	// Registered before the fields are walked, so recursive types end.
+	s.names[t] = name
+	s.components[name] = &Schema{}
+	*s.components[name] = *s.object(t)
+
+	return name
+}
+
+// object describes the fields of a struct. Embedded structs without a json
+// name are flattened, like encoding/json does.
+func (s *schemas) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	for _, f := range fields(t) {
+		if f.embedded {
+			embedded := s.object(deref(f.Type))
+			for name, property := range embedded.Properties {
+				schema.Properties[name] = property
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+
+		property, required := s.field(t, f.StructField)
+		schema.Properties[f.name] = property
+		if required {
+			schema.Required = append(schema.Required, f.name)
+		}
+	}
+
+	return schema
+}
+
+// field describes a struct field with the constraints of its validate tag.
+func (s *schemas) field(parent reflect.Type, f reflect.StructField) (*Schema, bool) {
+	schema := s.of(f.Type)
+	rules := strings.Split(f.Tag.Get("validate"), ",")
+
+	// Rules after dive are about the elements, keys are within keys/endkeys.
+	var elements []string
+	for i, rule := range rules {
+		if rule == "dive" {
+			rules, elements = rules[:i], rules[i+1:]
+			break
+		}
+	}
+
+	required := constrain(schema, deref(f.Type), rules, parent)
+
+	if len(elements) > 0 {
+		if keys, rest, ok := cutKeys(elements); ok {
+			schema.PropertyNames = &Schema{Type: "string"}
+			constrain(schema.PropertyNames, reflect.TypeOf(""), keys, parent)
+			elements = rest
+		}
+
+		// Components are shared, constraints of one field must not leak into them.
+		element := schema.AdditionalProperties
+		if schema.Items != nil {
+			element = schema.Items
+		}
+		if element != nil && element.Ref == "" {
+			elementType := deref(f.Type).Elem()
+			constrain(element, deref(elementType), elements, parent)
+		}
+	}
+
+	return schema, required
+}
+
+// constrain applies validator rules to schema and reports whether the value
+// is required.
+func constrain(schema *Schema, t reflect.Type, rules []string, parent reflect.Type) bool {
+	required := false
+
+	for _, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+
+		switch name {
+		case "required":
+			required = true
+		case "required_unless", "required_if":
+			if field, value, ok := strings.Cut(param, " "); ok {
+				condition := "unless"
+				if name == "required_if" {
+					condition = "if"
+				}
+				schema.Description = "Required " + condition + " " + jsonName(parent, field) + " is " + value
+			}
+		case "min", "gte":
+			bound(schema, t, param, true)
+		case "max", "lte":
+			bound(schema, t, param, false)
+		case "len":
+			bound(schema, t, param, true)
+			bound(schema, t, param, false)
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				schema.Enum = append(schema.Enum, typed(t, value))
+			}
+		case "email":
+			schema.Format = "email"
+		case "url":
+			schema.Format = "uri"
+		}
+	}
+
+	return required
+}
+
+// bound sets the lower or upper bound that min and max mean for t: the length
+// of a string, the size of a collection or the value of a number.
+func bound(schema *Schema, t reflect.Type, param string, lower bool) {
+	switch t.Kind() {
+	case reflect.String:
+		n, err := strconv.Atoi(param)
+		if err != nil {
+			return
+		}
+		if lower {
+			schema.MinLength = &n
+		} else {
+			schema.MaxLength = &n
+		}
+	case reflect.Slice, reflect.Array:
+		n, err := strconv.Atoi(param)
+		if err != nil {
+			return
+		}
+		if lower {
+			schema.MinItems = &n
+		} else {
+			schema.MaxItems = &n
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return
+		}
+		if lower {
+			schema.Minimum = &n
+		} else {
+			schema.Maximum = &n
+		}
+	}
+}
+
+// typed parses a oneof value as the type of the field.
+func typed(t reflect.Type, value string) any {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	case reflect.Float32, reflect.Float64:
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	}
+	return value
+}
+
+// cutKeys splits the rules of map keys off the rules after dive.
+func cutKeys(rules []string) (keys []string, rest []string, ok bool) {
+	if len(rules) == 0 || rules[0] != "keys" {
+		return nil, rules, false
+	}
+
+	for i, rule := range rules {
+		if rule == "endkeys" {
+			return rules[1:i], rules[i+1:], true
+		}
+	}
+
+	return nil, rules, false
+}
+
+type field struct {
+	reflect.StructField
+	name     string
+	embedded bool
+}
+
+// fields lists the fields of t that encoding/json writes, under their json
+// names.
+func fields(t reflect.Type) []field {
+	var result []field
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" && deref(f.Type).Kind() == reflect.Struct {
+			result = append(result, field{StructField: f, embedded: true})
+			continue
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		result = append(result, field{StructField: f, name: name})
+	}
+
+	return result
+}
+
+// jsonName is the json name of the field of t called name in Go.
+func jsonName(t reflect.Type, name string) string {
+	for _, f := range fields(t) {
+		if f.Name == name {
+			return f.name
+		}
+	}
+	return name
+}
+
+func deref(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
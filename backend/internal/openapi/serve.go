@@ -0,0 +1,91 @@
+package openapi
+
+import (
+	"api/internal/responses"
+	"bytes"
+	"compress/gzip"
+	"embed"
+	"encoding/json"
+	"io"
+	"mime"
+	"path"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// swagger is Swagger UI reading openapi.json next to it. Its assets are those
+// of swagger-ui-dist 5.29.1, stored gzipped; see swagger/NOTICE.
+//
+//go:embed swagger/index.html swagger/*.gz
+var swagger embed.FS
+
+// Handler serves the document of the app. It is built on the first request,
+// once every route is registered; divergences are reported by Build and do
+// not stop the document from being served.
+func Handler(info Info, endpoints map[string]Endpoint) fiber.Handler {
+	var (
+		once sync.Once
+		body []byte
+		err  error
+	)
+
+	return func(c *fiber.Ctx) error {
+		once.Do(func() {
+			doc, _ := Build(info, c.App().GetRoutes(true), endpoints)
+			body, err = json.Marshal(doc)
+		})
+
+		if err != nil {
+			return err
+		}
+
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+		return c.Send(body)
+	}
+}
+
+// Ui serves Swagger UI. The page links /api/docs/:file, where Assets has to be
+// mounted, and /api/openapi.json by absolute paths, so it works at /api/docs/
+// too.
+func Ui(c *fiber.Ctx) error {
+	page, err := swagger.ReadFile("swagger/index.html")
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Send(page)
+}
+
+// Assets serves the scripts and styles of Swagger UI by the :file parameter.
+// They are sent gzipped unless the client does not accept it.
+func Assets(c *fiber.Ctx) error {
+	name := path.Base(c.Params("file"))
+
+	body, err := swagger.ReadFile("swagger/" + name + ".gz")
+	if err != nil {
+		return responses.NewNotFoundError()
+	}
+
+	c.Set(fiber.HeaderContentType, mime.TypeByExtension(path.Ext(name)))
+	c.Set(fiber.HeaderCacheControl, "public, max-age=86400")
+	c.Vary(fiber.HeaderAcceptEncoding)
+
+	if c.AcceptsEncodings("gzip") == "gzip" {
+		c.Set(fiber.HeaderContentEncoding, "gzip")
+		return c.Send(body)
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	plain, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	return c.Send(plain)
+}
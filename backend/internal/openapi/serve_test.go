@@ -0,0 +1,78 @@
+package openapi
+
+import (
+	"api/internal/middlewares"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAssets(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	app := fiber.New(fiber.Config{ErrorHandler: middlewares.NewErrorHandler(logger)})
+	app.Get("/api/docs", Ui)
+	app.Get("/api/docs/:file", Assets)
+
+	tests := []struct {
+		name        string
+		path        string
+		gzip        bool
+		status      int
+		contentType string
+		encoding    string
+		prefix      string
+		contains    string
+	}{
+		{name: "page", path: "/api/docs", status: fiber.StatusOK, contentType: "text/html", prefix: "<!doctype html>", contains: `"/api/openapi.json"`},
+		{name: "page with a slash", path: "/api/docs/", status: fiber.StatusOK, contentType: "text/html", prefix: "<!doctype html>", contains: `src="/api/docs/swagger-ui-bundle.js"`},
+		{name: "gzipped script", path: "/api/docs/swagger-ui-bundle.js", gzip: true, status: fiber.StatusOK, contentType: "text/javascript", encoding: "gzip", prefix: "\x1f\x8b"},
+		{name: "plain style", path: "/api/docs/swagger-ui.css", status: fiber.StatusOK, contentType: "text/css", prefix: ".swagger-ui"},
+		{name: "unknown file", path: "/api/docs/index.html", status: fiber.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, tt.path, nil)
+			if tt.gzip {
+				req.Header.Set(fiber.HeaderAcceptEncoding, "gzip, br")
+			} else {
+				req.Header.Set(fiber.HeaderAcceptEncoding, "identity")
+			}
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if tt.status != fiber.StatusOK {
+				return
+			}
+
+			if contentType := resp.Header.Get(fiber.HeaderContentType); !strings.HasPrefix(contentType, tt.contentType) {
+				t.Errorf("Content-Type = %q, want %s", contentType, tt.contentType)
+			}
+			if encoding := resp.Header.Get(fiber.HeaderContentEncoding); encoding != tt.encoding {
+				t.Errorf("Content-Encoding = %q, want %q", encoding, tt.encoding)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(string(body), tt.prefix) {
+				t.Errorf("body starts with %q, want %q", body[:min(len(body), 16)], tt.prefix)
+			}
+			if !strings.Contains(string(body), tt.contains) {
+				t.Errorf("body does not have %s", tt.contains)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"api/internal/enums"
+	"context"
+)
+
+const (
+	CreateAuditEntrySql = "INSERT INTO audit_log (entity, entity_id, action, version) VALUES ($1, $2, $3, $4)"
+)
+
+// AuditRepository appends to the audit log. It is meant to be used from a unit
+// of work, so the entry is written together with the change it describes.
+type AuditRepository interface {
+	Record(ctx context.Context, entity enums.AuditEntity, entityId int, action enums.AuditAction, version int) error
+}
+
+type auditRepository struct {
+	db Querier
+}
+
+func NewAuditRepository(db Querier) AuditRepository {
+	return &auditRepository{db: observing(translating(db), "audit")}
+}
+
+func (a *auditRepository) Record(ctx context.Context, entity enums.AuditEntity, entityId int, action enums.AuditAction, version int) error {
+	_, err := a.db.Exec(ctx, CreateAuditEntrySql, entity, entityId, action, version)
+	return err
+}
@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"api/internal/domain"
+	"api/internal/enums"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// uniqueFields names the api field guarded by each unique constraint or index.
+var uniqueFields = map[string]string{
+	"pastes_title_key":             "title",
+	"idx_pastes_title":             "title",
+	"users_username_key":           "username",
+	"idx_users_username":           "username",
+	"users_social_id_key":          "socialId",
+	"idx_users_display_username":   "displayName",
+	"idx_saved_searches_user_name": "name",
+}
+
+// translateError turns Postgres errors the caller can act on into domain
+// errors and passes everything else through. A query cancelled by
+// statement_timeout becomes context.DeadlineExceeded, like one cancelled by
+// the deadline of the request.
+func translateError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	if pgErr.Code == enums.DbCodeQueryCanceled {
+		return fmt.Errorf("%w: %s", context.DeadlineExceeded, pgErr.Message)
+	}
+
+	if pgErr.Code != enums.DbCodeDuplicateKey {
+		return err
+	}
+
+	if field, ok := uniqueFields[pgErr.ConstraintName]; ok {
+		return domain.NewConflictError("Already exists", field)
+	}
+	return domain.NewConflictError("Already exists")
+}
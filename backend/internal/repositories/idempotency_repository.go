@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"api/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	DeleteExpiredIdempotencyKeySql = "DELETE FROM idempotency_keys WHERE key=$1 AND expires_at < now()"
+	ClaimIdempotencyKeySql         = "INSERT INTO idempotency_keys (key, request_hash, expires_at) VALUES ($1, $2, now() + $3::interval) ON CONFLICT (key) DO NOTHING"
+	FindIdempotencyKeySql          = "SELECT key, request_hash, status_code, content_type, response_body, response_headers, created_at, expires_at FROM idempotency_keys WHERE key=$1"
+	CompleteIdempotencyKeySql      = "UPDATE idempotency_keys SET status_code=$2, content_type=$3, response_body=$4, response_headers=$5 WHERE key=$1"
+	ReleaseIdempotencyKeySql       = "DELETE FROM idempotency_keys WHERE key=$1 AND status_code IS NULL"
+	PurgeIdempotencyKeysSql        = "DELETE FROM idempotency_keys WHERE expires_at < now()"
+)
+
+type IdempotencyRepository interface {
+	Claim(ctx context.Context, key string, requestHash string, ttl time.Duration) (bool, error)
+	Find(ctx context.Context, key string) (*models.IdempotencyKeyModel, error)
+	Complete(ctx context.Context, key string, statusCode int, contentType string, headers map[string]string, body []byte) error
+	Release(ctx context.Context, key string) error
+	Purge(ctx context.Context) (int64, error)
+}
+
+type idempotencyRepository struct {
+	db Querier
+}
+
+func NewIdempotencyRepository(p *pgxpool.Pool) IdempotencyRepository {
+	return &idempotencyRepository{db: observing(p, "idempotency")}
+}
+
+// Claim reserves the key for a new request. It returns false when the key is
+// already taken by a request that has not expired yet.
+func (i *idempotencyRepository) Claim(ctx context.Context, key string, requestHash string, ttl time.Duration) (bool, error) {
+	if _, err := i.db.Exec(ctx, DeleteExpiredIdempotencyKeySql, key); err != nil {
+		return false, err
+	}
+
+	tag, err := i.db.Exec(ctx, ClaimIdempotencyKeySql, key, requestHash, ttl)
+
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+func (i *idempotencyRepository) Find(ctx context.Context, key string) (*models.IdempotencyKeyModel, error) {
+	var record models.IdempotencyKeyModel
+
+	err := i.db.QueryRow(ctx, FindIdempotencyKeySql, key).Scan(
+		&record.Key,
+		&record.RequestHash,
+		&record.StatusCode,
+		&record.ContentType,
+		&record.ResponseBody,
+		&record.ResponseHeaders,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (i *idempotencyRepository) Complete(ctx context.Context, key string, statusCode int, contentType string, headers map[string]string, body []byte) error {
+	_, err := i.db.Exec(ctx, CompleteIdempotencyKeySql, key, statusCode, contentType, body, headers)
+	return err
+}
+
+// Release frees a claimed key whose request did not produce a response worth replaying.
+func (i *idempotencyRepository) Release(ctx context.Context, key string) error {
+	_, err := i.db.Exec(ctx, ReleaseIdempotencyKeySql, key)
+	return err
+}
+
+// Purge deletes every expired key and returns how many there were.
+func (i *idempotencyRepository) Purge(ctx context.Context) (int64, error) {
+	tag, err := i.db.Exec(ctx, PurgeIdempotencyKeysSql)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,11 @@
+package repositories
+
+import "strings"
+
+var likeReplacer = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// prefixPattern builds a case-insensitive LIKE pattern matching values that
+// start with text. The value must be compared as lower(column) LIKE pattern.
+func prefixPattern(text string) string {
+	return likeReplacer.Replace(strings.ToLower(text)) + "%"
+}
@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"api/internal/metrics"
+	"context"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// observing wraps db so the duration of every query, up to the last row read,
+// ends up in metrics.QueryDuration labelled with the repository and the
+// method that ran it.
+func observing(db Querier, repository string) Querier {
+	return &observedQuerier{db: db, repository: repository}
+}
+
+type observedQuerier struct {
+	db         Querier
+	repository string
+}
+
+func (q *observedQuerier) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	observe := q.start()
+	defer observe()
+
+	return q.db.Exec(ctx, sql, args...)
+}
+
+func (q *observedQuerier) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	observe := q.start()
+
+	rows, err := q.db.Query(ctx, sql, args...)
+	if err != nil {
+		observe()
+		return nil, err
+	}
+
+	return &observedRows{Rows: rows, observe: observe}, nil
+}
+
+func (q *observedQuerier) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	observe := q.start()
+
+	return &observedRow{row: q.db.QueryRow(ctx, sql, args...), observe: observe}
+}
+
+// start must be called right from the Querier method, the caller of which is
+// the repository method.
+func (q *observedQuerier) start() func() {
+	started := time.Now()
+	method := callerMethod(3)
+
+	return func() {
+		metrics.QueryDuration.WithLabelValues(q.repository, method).Observe(time.Since(started).Seconds())
+	}
+}
+
+// callerMethod names the function skip frames up, without the package,
+// the receiver and the suffixes of closures.
+func callerMethod(skip int) string {
+	pc, _, _, ok := runtime.Caller(skip)
+	if !ok {
+		return "unknown"
+	}
+
+	name := runtime.FuncForPC(pc).Name()
+	name = name[strings.LastIndex(name, "/")+1:]
+
+	parts := strings.Split(name, ".")
+	for _, part := range parts[1:] {
+		if !strings.HasPrefix(part, "(") {
+			return part
+		}
+	}
+
+	return name
+}
+
+type observedRows struct {
+	pgx.Rows
+	observe  func()
+	observed bool
+}
+
+func (r *observedRows) Next() bool {
+	next := r.Rows.Next()
+	if !next {
+		r.done()
+	}
+	return next
+}
+
+func (r *observedRows) Close() {
+	r.Rows.Close()
+	r.done()
+}
+
+func (r *observedRows) done() {
+	if !r.observed {
+		r.observed = true
+		r.observe()
+	}
+}
+
+type observedRow struct {
+	row     pgx.Row
+	observe func()
+}
+
+func (r *observedRow) Scan(dest ...any) error {
+	defer r.observe()
+
+	return r.row.Scan(dest...)
+}
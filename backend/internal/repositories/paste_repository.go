@@ -2,45 +2,78 @@ package repositories
 
 import (
 	"api/internal/dtos"
+	"api/internal/enums"
 	"api/internal/models"
 	"context"
 	"errors"
 	"fmt"
 	"strings"
 
-	"github.com/gofiber/fiber/v2/log"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 const (
-	CreatePasteSql = "INSERT INTO pastes (title, paste, user_id) VALUES ($1, $2, $3) RETURNING id, title, paste, user_id, created_at, updated_at"
-	FindPasteSql   = "SELECT id, title, paste, user_id, created_at, updated_at FROM pastes %s"
-	UpdatePasteSql = "UPDATE pastes SET title=$1, paste=$2, updated_at=now() %s RETURNING id, title, paste, user_id, created_at, updated_at"
+	CreatePasteSql = "INSERT INTO pastes (title, paste, user_id) VALUES ($1, $2, $3) RETURNING id, title, paste, user_id, created_at, updated_at, version"
+	FindPasteSql   = "SELECT id, title, paste, user_id, created_at, updated_at, version FROM pastes %s"
+	UpdatePasteSql = "UPDATE pastes SET title=$1, paste=$2, updated_at=now(), version=version+1 %s RETURNING id, title, paste, user_id, created_at, updated_at, version"
+	PatchPasteSql  = "UPDATE pastes SET %s, updated_at=now(), version=version+1 %s RETURNING id, title, paste, user_id, created_at, updated_at, version"
 	DeletePasteSql = "DELETE FROM pastes %s"
+	ExportPasteSql = "SELECT id, title, paste, (SELECT social_id FROM users WHERE users.id = pastes.user_id), created_at, updated_at FROM pastes %s ORDER BY id"
 )
 
+const (
+	SuggestPasteSql = `SELECT id, title FROM pastes
+		WHERE (lower(title) LIKE $1 OR title %% $2) %s
+		ORDER BY lower(title) LIKE $1 DESC, similarity(title, $2) DESC, title
+		LIMIT $3`
+)
+
+const (
+	AuthorFacetSql = "SELECT user_id::text, count(*) FROM pastes %s GROUP BY user_id ORDER BY count(*) DESC, user_id LIMIT 50"
+	TagFacetSql    = "SELECT tag, count(*) FROM pastes CROSS JOIN LATERAL unnest(tags) AS tag %s GROUP BY tag ORDER BY count(*) DESC, tag LIMIT 50"
+	MonthFacetSql  = "SELECT to_char(date_trunc('month', created_at), 'YYYY-MM') AS month, count(*) FROM pastes %s GROUP BY month ORDER BY month DESC"
+	LengthFacetSql = `SELECT bucket, count(*) FROM (
+		SELECT CASE
+			WHEN char_length(paste) < 256 THEN '0-255'
+			WHEN char_length(paste) < 512 THEN '256-511'
+			WHEN char_length(paste) < 1024 THEN '512-1023'
+			ELSE '1024+'
+		END AS bucket, char_length(paste) AS length FROM pastes %s
+	) AS buckets GROUP BY bucket ORDER BY min(length)`
+)
+
+var facetSql = map[enums.Facet]string{
+	enums.FacetAuthor: AuthorFacetSql,
+	enums.FacetTag:    TagFacetSql,
+	enums.FacetMonth:  MonthFacetSql,
+	enums.FacetLength: LengthFacetSql,
+}
+
 type PasteRepository interface {
-	FindOne(filter *dtos.PastesFilterDto, pagination *dtos.PaginationDto) (*models.PasteModel, error)
-	FindMany(filter *dtos.PastesFilterDto, pagination *dtos.PaginationDto) ([]*models.PasteModel, error)
-	Create(dto *dtos.PasteDto) (*models.PasteModel, error)
-	Update(filter *dtos.PastesFilterDto, dto *dtos.UpdatePasteDto) (*models.PasteModel, error)
-	Delete(filter *dtos.PastesFilterDto) (bool, error)
+	FindOne(ctx context.Context, filter *dtos.PastesFilterDto, pagination *dtos.PaginationDto) (*models.PasteModel, error)
+	FindMany(ctx context.Context, filter *dtos.PastesFilterDto, pagination *dtos.PaginationDto) ([]*models.PasteModel, error)
+	Create(ctx context.Context, dto *dtos.PasteDto) (*models.PasteModel, error)
+	Update(ctx context.Context, filter *dtos.PastesFilterDto, dto *dtos.UpdatePasteDto, version *int) (*models.PasteModel, error)
+	Patch(ctx context.Context, filter *dtos.PastesFilterDto, dto *dtos.PatchPasteDto, version *int) (*models.PasteModel, error)
+	Delete(ctx context.Context, filter *dtos.PastesFilterDto, version *int) (bool, error)
+	Suggest(ctx context.Context, query *dtos.SuggestQueryDto, limit int) ([]*models.SuggestionModel, error)
+	Facets(ctx context.Context, filter *dtos.PastesFilterDto, facets []enums.Facet) (map[enums.Facet][]models.FacetBucket, error)
+	Export(ctx context.Context, filter *dtos.PastesFilterDto, fn func(record *models.PasteRecordModel) error) error
 }
 
 type pasteRepository struct {
-	pool *pgxpool.Pool
+	db Querier
 }
 
-func NewPasteRepository(p *pgxpool.Pool) PasteRepository {
-	return &pasteRepository{pool: p}
+func NewPasteRepository(db Querier) PasteRepository {
+	return &pasteRepository{db: observing(translating(db), "pastes")}
 }
 
-func (p *pasteRepository) FindOne(filter *dtos.PastesFilterDto, pagination *dtos.PaginationDto) (*models.PasteModel, error) {
+func (p *pasteRepository) FindOne(ctx context.Context, filter *dtos.PastesFilterDto, pagination *dtos.PaginationDto) (*models.PasteModel, error) {
 	var paste models.PasteModel
 	condition, args := p.buildFilters(filter, 0, pagination)
-	err := p.pool.
-		QueryRow(context.Background(), fmt.Sprintf(FindPasteSql, condition), args...).
+	err := p.db.
+		QueryRow(ctx, fmt.Sprintf(FindPasteSql, condition), args...).
 		Scan(
 			&paste.Id,
 			&paste.Title,
@@ -48,6 +81,7 @@ func (p *pasteRepository) FindOne(filter *dtos.PastesFilterDto, pagination *dtos
 			&paste.UserId,
 			&paste.CreatedAt,
 			&paste.UpdatedAt,
+			&paste.Version,
 		)
 
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -61,9 +95,9 @@ func (p *pasteRepository) FindOne(filter *dtos.PastesFilterDto, pagination *dtos
 	return &paste, nil
 }
 
-func (p *pasteRepository) FindMany(filter *dtos.PastesFilterDto, pagination *dtos.PaginationDto) ([]*models.PasteModel, error) {
+func (p *pasteRepository) FindMany(ctx context.Context, filter *dtos.PastesFilterDto, pagination *dtos.PaginationDto) ([]*models.PasteModel, error) {
 	condition, args := p.buildFilters(filter, 0, pagination)
-	rows, err := p.pool.Query(context.Background(), fmt.Sprintf(FindPasteSql, condition), args...)
+	rows, err := p.db.Query(ctx, fmt.Sprintf(FindPasteSql, condition), args...)
 
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
@@ -86,6 +120,7 @@ func (p *pasteRepository) FindMany(filter *dtos.PastesFilterDto, pagination *dto
 				&paste.UserId,
 				&paste.CreatedAt,
 				&paste.UpdatedAt,
+				&paste.Version,
 			)
 		if err != nil {
 			return nil, err
@@ -96,41 +131,47 @@ func (p *pasteRepository) FindMany(filter *dtos.PastesFilterDto, pagination *dto
 	return pastes, nil
 }
 
-func (p *pasteRepository) Create(dto *dtos.PasteDto) (*models.PasteModel, error) {
+func (p *pasteRepository) Create(ctx context.Context, dto *dtos.PasteDto) (*models.PasteModel, error) {
 	var paste models.PasteModel
 
-	err := p.pool.QueryRow(context.Background(), CreatePasteSql, dto.Title, dto.Paste, dto.UserId).Scan(
+	err := p.db.QueryRow(ctx, CreatePasteSql, dto.Title, dto.Paste, dto.UserId).Scan(
 		&paste.Id,
 		&paste.Title,
 		&paste.Paste,
 		&paste.UserId,
 		&paste.CreatedAt,
 		&paste.UpdatedAt,
+		&paste.Version,
 	)
 
 	if err != nil {
-		log.Error(err)
 		return nil, err
 	}
 
 	return &paste, nil
 }
 
-func (p *pasteRepository) Update(filter *dtos.PastesFilterDto, dto *dtos.UpdatePasteDto) (*models.PasteModel, error) {
+func (p *pasteRepository) Update(ctx context.Context, filter *dtos.PastesFilterDto, dto *dtos.UpdatePasteDto, version *int) (*models.PasteModel, error) {
 	var paste models.PasteModel
 	condition, args := p.buildFilters(filter, 2, nil)
+	condition, args = withVersion(condition, args, 2+len(args)+1, version)
 
 	lastArgs := append([]any{dto.Title, dto.Paste}, args...)
 
-	err := p.pool.QueryRow(context.Background(), fmt.Sprintf(UpdatePasteSql, condition), lastArgs...).Scan(
+	err := p.db.QueryRow(ctx, fmt.Sprintf(UpdatePasteSql, condition), lastArgs...).Scan(
 		&paste.Id,
 		&paste.Title,
 		&paste.Paste,
 		&paste.UserId,
 		&paste.CreatedAt,
 		&paste.UpdatedAt,
+		&paste.Version,
 	)
 
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+
 	if err != nil {
 		return nil, err
 	}
@@ -138,16 +179,166 @@ func (p *pasteRepository) Update(filter *dtos.PastesFilterDto, dto *dtos.UpdateP
 	return &paste, nil
 }
 
-func (p *pasteRepository) Delete(filter *dtos.PastesFilterDto) (bool, error) {
+// Patch updates only the columns whose fields are set in dto.
+func (p *pasteRepository) Patch(ctx context.Context, filter *dtos.PastesFilterDto, dto *dtos.PatchPasteDto, version *int) (*models.PasteModel, error) {
+	var paste models.PasteModel
+	var sets []string
+	var values []any
+
+	if dto.Title != nil {
+		values = append(values, *dto.Title)
+		sets = append(sets, fmt.Sprintf("title=$%d", len(values)))
+	}
+
+	if dto.Paste != nil {
+		values = append(values, *dto.Paste)
+		sets = append(sets, fmt.Sprintf("paste=$%d", len(values)))
+	}
+
+	if len(sets) == 0 {
+		return p.FindOne(ctx, filter, nil)
+	}
+
+	condition, args := p.buildFilters(filter, len(values), nil)
+	condition, args = withVersion(condition, args, len(values)+len(args)+1, version)
+
+	err := p.db.QueryRow(ctx, fmt.Sprintf(PatchPasteSql, strings.Join(sets, ", "), condition), append(values, args...)...).Scan(
+		&paste.Id,
+		&paste.Title,
+		&paste.Paste,
+		&paste.UserId,
+		&paste.CreatedAt,
+		&paste.UpdatedAt,
+		&paste.Version,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &paste, nil
+}
+
+func (p *pasteRepository) Delete(ctx context.Context, filter *dtos.PastesFilterDto, version *int) (bool, error) {
 	condition, args := p.buildFilters(filter, 0, nil)
+	condition, args = withVersion(condition, args, len(args)+1, version)
 
-	_, err := p.pool.Query(context.Background(), fmt.Sprintf(DeletePasteSql, condition), args...)
+	tag, err := p.db.Exec(ctx, fmt.Sprintf(DeletePasteSql, condition), args...)
 
 	if err != nil {
 		return false, err
 	}
 
-	return true, nil
+	return tag.RowsAffected() > 0, nil
+}
+
+func (p *pasteRepository) Suggest(ctx context.Context, query *dtos.SuggestQueryDto, limit int) ([]*models.SuggestionModel, error) {
+	text := ""
+	if query.Query != nil {
+		text = strings.TrimSpace(*query.Query)
+	}
+
+	scope := ""
+	args := []any{prefixPattern(text), text, limit}
+
+	if query.UserId != nil {
+		scope = "AND user_id=$4"
+		args = append(args, query.UserId)
+	} else if query.SocialId != nil {
+		scope = "AND user_id = (SELECT id FROM users WHERE social_id=$4)"
+		args = append(args, query.SocialId)
+	}
+
+	rows, err := p.db.Query(ctx, fmt.Sprintf(SuggestPasteSql, scope), args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.SuggestionModel, error) {
+		var suggestion models.SuggestionModel
+		err := row.Scan(&suggestion.Id, &suggestion.Label)
+		return &suggestion, err
+	})
+}
+
+func (p *pasteRepository) Facets(ctx context.Context, filter *dtos.PastesFilterDto, facets []enums.Facet) (map[enums.Facet][]models.FacetBucket, error) {
+	condition, args := p.buildFilters(filter, 0, nil)
+	result := map[enums.Facet][]models.FacetBucket{}
+
+	for _, facet := range facets {
+		rows, err := p.db.Query(ctx, fmt.Sprintf(facetSql[facet], condition), args...)
+
+		if err != nil {
+			return nil, err
+		}
+
+		buckets, err := collectBuckets(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result[facet] = buckets
+	}
+
+	return result, nil
+}
+
+// collectBuckets reads value and count rows. A NULL tag or creation time has
+// no value to show, its bucket is left out.
+func collectBuckets(rows pgx.Rows) ([]models.FacetBucket, error) {
+	buckets := []models.FacetBucket{}
+
+	var (
+		value *string
+		count int
+	)
+	_, err := pgx.ForEachRow(rows, []any{&value, &count}, func() error {
+		if value != nil {
+			buckets = append(buckets, models.FacetBucket{Value: *value, Count: count})
+		}
+		return nil
+	})
+
+	return buckets, err
+}
+
+// Export calls fn for every paste matching filter in id order without loading
+// them all at once. An error returned by fn stops the export.
+func (p *pasteRepository) Export(ctx context.Context, filter *dtos.PastesFilterDto, fn func(record *models.PasteRecordModel) error) error {
+	condition, args := p.buildFilters(filter, 0, nil)
+	rows, err := p.db.Query(ctx, fmt.Sprintf(ExportPasteSql, condition), args...)
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var record models.PasteRecordModel
+		err := rows.Scan(
+			&record.Id,
+			&record.Title,
+			&record.Paste,
+			&record.SocialId,
+			&record.CreatedAt,
+			&record.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(&record); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
 }
 
 func (p *pasteRepository) buildFilters(filter *dtos.PastesFilterDto, startFrom int, pagination *dtos.PaginationDto) (string, []interface{}) {
@@ -163,7 +354,8 @@ func (p *pasteRepository) buildFilters(filter *dtos.PastesFilterDto, startFrom i
 				conditions = append(conditions, fmt.Sprintf("title = $%d", position))
 				args = append(args, *filter.Search)
 			} else {
-				conditions = append(conditions, fmt.Sprintf("title ILIKE $%d", position))
+				// The body is searched too, so snippets have a match to show.
+				conditions = append(conditions, fmt.Sprintf("(title ILIKE $%d OR paste ILIKE $%d)", position, position))
 				args = append(args, "%"+*filter.Search+"%")
 			}
 		}
@@ -208,9 +400,10 @@ func (p *pasteRepository) buildFilters(filter *dtos.PastesFilterDto, startFrom i
 	}
 
 	if pagination != nil {
+		// The direction is written into the query, so nothing but DESC gets through.
 		sort := "ASC"
-		if pagination.Sort != nil {
-			sort = *pagination.Sort
+		if pagination.Sort != nil && *pagination.Sort == "DESC" {
+			sort = "DESC"
 		}
 		sortStr := fmt.Sprintf(" ORDER BY id %s", sort)
 		condition += sortStr
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"api/internal/models"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// fakeRows returns value and count rows; a nil value is a NULL.
+type fakeRows struct {
+	pgx.Rows
+	values []*string
+	counts []int
+	next   int
+}
+
+func (r *fakeRows) Next() bool {
+	r.next++
+	return r.next <= len(r.values)
+}
+
+func (r *fakeRows) Scan(dest ...any) error {
+	*dest[0].(**string) = r.values[r.next-1]
+	*dest[1].(*int) = r.counts[r.next-1]
+	return nil
+}
+
+func (r *fakeRows) Err() error                    { return nil }
+func (r *fakeRows) Close()                        {}
+func (r *fakeRows) CommandTag() pgconn.CommandTag { return pgconn.CommandTag{} }
+
+func TestCollectBuckets(t *testing.T) {
+	text := func(value string) *string { return &value }
+
+	tests := []struct {
+		name   string
+		values []*string
+		counts []int
+		want   []models.FacetBucket
+	}{
+		{
+			name: "no rows",
+			want: []models.FacetBucket{},
+		},
+		{
+			name:   "values",
+			values: []*string{text("go"), text("sql")},
+			counts: []int{3, 1},
+			want:   []models.FacetBucket{{Value: "go", Count: 3}, {Value: "sql", Count: 1}},
+		},
+		{
+			name:   "null tag or month is left out",
+			values: []*string{text("go"), nil, text("2025-08")},
+			counts: []int{3, 2, 1},
+			want:   []models.FacetBucket{{Value: "go", Count: 3}, {Value: "2025-08", Count: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := collectBuckets(&fakeRows{values: tt.values, counts: tt.counts})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buckets = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Querier is the part of pgx shared by the pool and transactions, so the same
+// repository code can run inside or outside of a transaction.
+type Querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// translating wraps db so every error it hands out has been through
+// translateError, including the ones surfacing while reading rows.
+func translating(db Querier) Querier {
+	return &translatingQuerier{db: db}
+}
+
+type translatingQuerier struct {
+	db Querier
+}
+
+func (q *translatingQuerier) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	tag, err := q.db.Exec(ctx, sql, args...)
+	return tag, translateError(err)
+}
+
+func (q *translatingQuerier) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	rows, err := q.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &translatingRows{Rows: rows}, nil
+}
+
+func (q *translatingQuerier) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return &translatingRow{row: q.db.QueryRow(ctx, sql, args...)}
+}
+
+type translatingRows struct {
+	pgx.Rows
+}
+
+func (r *translatingRows) Err() error {
+	return translateError(r.Rows.Err())
+}
+
+type translatingRow struct {
+	row pgx.Row
+}
+
+func (r *translatingRow) Scan(dest ...any) error {
+	return translateError(r.row.Scan(dest...))
+}
@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"api/internal/models"
+	"context"
+)
+
+const (
+	CreatePasteRevisionSql = "INSERT INTO paste_revisions (paste_id, version, title, paste) VALUES ($1, $2, $3, $4)"
+)
+
+// RevisionRepository keeps every version of a paste.
+type RevisionRepository interface {
+	Create(ctx context.Context, paste *models.PasteModel) error
+}
+
+type revisionRepository struct {
+	db Querier
+}
+
+func NewRevisionRepository(db Querier) RevisionRepository {
+	return &revisionRepository{db: observing(translating(db), "revisions")}
+}
+
+func (r *revisionRepository) Create(ctx context.Context, paste *models.PasteModel) error {
+	_, err := r.db.Exec(ctx, CreatePasteRevisionSql, paste.Id, paste.Version, paste.Title, paste.Paste)
+	return err
+}
@@ -0,0 +1,183 @@
+package repositories
+
+import (
+	"api/internal/dtos"
+	"api/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	CreateSavedSearchSql = "INSERT INTO saved_searches (user_id, name, query) VALUES ($1, $2, $3) RETURNING id, user_id, name, query, created_at, updated_at"
+	FindSavedSearchSql   = "SELECT id, user_id, name, query, created_at, updated_at FROM saved_searches %s"
+	UpdateSavedSearchSql = "UPDATE saved_searches SET name=$1, query=$2, updated_at=now() WHERE id=$3 RETURNING id, user_id, name, query, created_at, updated_at"
+	DeleteSavedSearchSql = "DELETE FROM saved_searches WHERE id=$1"
+)
+
+type SavedSearchRepository interface {
+	FindOne(ctx context.Context, filter *dtos.SavedSearchFilterDto) (*models.SavedSearchModel, error)
+	FindById(ctx context.Context, id int) (*models.SavedSearchModel, error)
+	FindMany(ctx context.Context, filter *dtos.SavedSearchFilterDto) ([]*models.SavedSearchModel, error)
+	Create(ctx context.Context, dto *dtos.SavedSearchDto) (*models.SavedSearchModel, error)
+	Update(ctx context.Context, id int, dto *dtos.UpdateSavedSearchDto) (*models.SavedSearchModel, error)
+	Delete(ctx context.Context, id int) (bool, error)
+}
+
+type savedSearchRepository struct {
+	db Querier
+}
+
+func NewSavedSearchRepository(db Querier) SavedSearchRepository {
+	return &savedSearchRepository{db: observing(translating(db), "saved_searches")}
+}
+
+func (s *savedSearchRepository) FindOne(ctx context.Context, filter *dtos.SavedSearchFilterDto) (*models.SavedSearchModel, error) {
+	condition, args := s.buildFilters(filter, 0)
+	return s.findOne(ctx, fmt.Sprintf(FindSavedSearchSql, condition), args...)
+}
+
+func (s *savedSearchRepository) FindById(ctx context.Context, id int) (*models.SavedSearchModel, error) {
+	return s.findOne(ctx, fmt.Sprintf(FindSavedSearchSql, "WHERE id=$1"), id)
+}
+
+func (s *savedSearchRepository) findOne(ctx context.Context, sql string, args ...any) (*models.SavedSearchModel, error) {
+	var search models.SavedSearchModel
+
+	err := s.db.
+		QueryRow(ctx, sql, args...).
+		Scan(
+			&search.Id,
+			&search.UserId,
+			&search.Name,
+			&search.Query,
+			&search.CreatedAt,
+			&search.UpdatedAt,
+		)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &search, nil
+}
+
+func (s *savedSearchRepository) FindMany(ctx context.Context, filter *dtos.SavedSearchFilterDto) ([]*models.SavedSearchModel, error) {
+	condition, args := s.buildFilters(filter, 0)
+	rows, err := s.db.Query(ctx, fmt.Sprintf(FindSavedSearchSql, condition)+" ORDER BY name ASC", args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var searches []*models.SavedSearchModel = []*models.SavedSearchModel{}
+
+	defer rows.Close()
+	for rows.Next() {
+		var search models.SavedSearchModel
+		err := rows.Scan(
+			&search.Id,
+			&search.UserId,
+			&search.Name,
+			&search.Query,
+			&search.CreatedAt,
+			&search.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		searches = append(searches, &search)
+	}
+
+	return searches, rows.Err()
+}
+
+func (s *savedSearchRepository) Create(ctx context.Context, dto *dtos.SavedSearchDto) (*models.SavedSearchModel, error) {
+	var search models.SavedSearchModel
+
+	err := s.db.QueryRow(ctx, CreateSavedSearchSql, dto.UserId, dto.Name, dto.Query).Scan(
+		&search.Id,
+		&search.UserId,
+		&search.Name,
+		&search.Query,
+		&search.CreatedAt,
+		&search.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &search, nil
+}
+
+func (s *savedSearchRepository) Update(ctx context.Context, id int, dto *dtos.UpdateSavedSearchDto) (*models.SavedSearchModel, error) {
+	var search models.SavedSearchModel
+
+	err := s.db.QueryRow(ctx, UpdateSavedSearchSql, dto.Name, dto.Query, id).Scan(
+		&search.Id,
+		&search.UserId,
+		&search.Name,
+		&search.Query,
+		&search.CreatedAt,
+		&search.UpdatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &search, nil
+}
+
+func (s *savedSearchRepository) Delete(ctx context.Context, id int) (bool, error) {
+	tag, err := s.db.Exec(ctx, DeleteSavedSearchSql, id)
+
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// buildFilters joins conditions with AND: a saved search is always scoped to its owner.
+func (s *savedSearchRepository) buildFilters(filter *dtos.SavedSearchFilterDto, startFrom int) (string, []any) {
+	var conditions []string = []string{}
+	var args []any = []any{}
+	var position int = startFrom
+
+	if filter.UserId != nil {
+		position++
+		conditions = append(conditions, fmt.Sprintf("user_id=$%d", position))
+		args = append(args, filter.UserId)
+	}
+
+	if filter.SocialId != nil {
+		position++
+		conditions = append(conditions, fmt.Sprintf("user_id = (SELECT id FROM users WHERE social_id=$%d)", position))
+		args = append(args, filter.SocialId)
+	}
+
+	if filter.Name != nil {
+		position++
+		conditions = append(conditions, fmt.Sprintf("name=$%d", position))
+		args = append(args, filter.Name)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"api/internal/enums"
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	unitOfWorkAttempts = 3
+	unitOfWorkBackoff  = 20 * time.Millisecond
+)
+
+// Repositories are the repositories of a unit of work, all bound to its
+// transaction.
+type Repositories struct {
+	Pastes        PasteRepository
+	Users         UserRepository
+	SavedSearches SavedSearchRepository
+	Audit         AuditRepository
+	Revisions     RevisionRepository
+}
+
+// UnitOfWork runs fn in a serializable transaction, committed if fn returns nil
+// and rolled back otherwise. When Postgres aborts the transaction because of a
+// concurrent one, fn is run again from scratch, so it must not keep state
+// between attempts.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(r *Repositories) error) error
+	// Stream runs fn once in a read-only transaction without statement_timeout,
+	// for reads handed to the client as slowly as it takes them. It is never
+	// retried, fn may have sent part of its output already.
+	Stream(ctx context.Context, fn func(r *Repositories) error) error
+}
+
+type unitOfWork struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewUnitOfWork(pool *pgxpool.Pool, logger *slog.Logger) UnitOfWork {
+	return &unitOfWork{pool: pool, logger: logger}
+}
+
+func (u *unitOfWork) Do(ctx context.Context, fn func(r *Repositories) error) error {
+	var err error
+
+	for attempt := 1; attempt <= unitOfWorkAttempts; attempt++ {
+		err = pgx.BeginTxFunc(ctx, u.pool, pgx.TxOptions{IsoLevel: pgx.Serializable}, func(tx pgx.Tx) error {
+			return fn(bind(tx, u.logger))
+		})
+
+		if !isRetryable(err) || attempt == unitOfWorkAttempts {
+			break
+		}
+
+		// Jitter keeps the transactions that collided from colliding again.
+		backoff := unitOfWorkBackoff*time.Duration(attempt) + rand.N(unitOfWorkBackoff)
+		u.logger.DebugContext(ctx, "Retrying transaction", "attempt", attempt, "backoff", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+
+	return translateError(err)
+}
+
+func (u *unitOfWork) Stream(ctx context.Context, fn func(r *Repositories) error) error {
+	err := pgx.BeginTxFunc(ctx, u.pool, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "SET LOCAL statement_timeout = 0"); err != nil {
+			return err
+		}
+		return fn(bind(tx, u.logger))
+	})
+
+	return translateError(err)
+}
+
+func bind(db Querier, logger *slog.Logger) *Repositories {
+	return &Repositories{
+		Pastes:        NewPasteRepository(db),
+		Users:         NewUserRepository(db, logger),
+		SavedSearches: NewSavedSearchRepository(db),
+		Audit:         NewAuditRepository(db),
+		Revisions:     NewRevisionRepository(db),
+	}
+}
+
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) &&
+		(pgErr.Code == enums.DbCodeSerializationFailure || pgErr.Code == enums.DbCodeDeadlockDetected)
+}
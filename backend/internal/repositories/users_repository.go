@@ -6,43 +6,61 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"strings"
 
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 const (
-	FindUserSql   = "SELECT id, username, display_name, social_id FROM users %s"
-	CreateUserSql = "INSERT INTO users (username, display_name, social_id) VALUES ($1, $2, $3) RETURNING id, username, display_name, social_id"
-	UpdateUserSql = "UPDATE users SET username=$1, display_name=$2 %s RETURNING id, username, display_name, social_id"
-	DeleteUserSql = "DELETE FROM users %s"
+	FindUserSql       = "SELECT id, username, display_name, social_id, created_at, updated_at, version FROM users %s"
+	FindUsersByIdsSql = "SELECT id, username, display_name, social_id, created_at, updated_at, version FROM users WHERE id = ANY($1)"
+	CreateUserSql     = "INSERT INTO users (username, display_name, social_id) VALUES ($1, $2, $3) RETURNING id, username, display_name, social_id, created_at, updated_at, version"
+	UpdateUserSql     = "UPDATE users SET username=$1, display_name=$2, updated_at=now(), version=version+1 %s RETURNING id, username, display_name, social_id, created_at, updated_at, version"
+	PatchUserSql      = "UPDATE users SET %s, updated_at=now(), version=version+1 %s RETURNING id, username, display_name, social_id, created_at, updated_at, version"
+	DeleteUserSql     = "DELETE FROM users %s"
+)
+
+const (
+	SuggestUserSql = `SELECT id, display_name || ' (' || username || ')' FROM users
+		WHERE lower(username) LIKE $1 OR lower(display_name) LIKE $1 OR username % $2 OR display_name % $2
+		ORDER BY (lower(username) LIKE $1 OR lower(display_name) LIKE $1) DESC,
+			greatest(similarity(username, $2), similarity(display_name, $2)) DESC,
+			username
+		LIMIT $3`
 )
 
 type UserRepository interface {
-	Create(dto *dtos.UserDto) (*models.UserModel, error)
-	Find(filter *dtos.UserFiltersDto) (*models.UserModel, error)
-	Update(filter *dtos.UserFiltersDto, dto *dtos.UpdateUserDto) (*models.UserModel, error)
-	Delete(filter *dtos.UserFiltersDto) (bool, error)
+	Create(ctx context.Context, dto *dtos.UserDto) (*models.UserModel, error)
+	Find(ctx context.Context, filter *dtos.UserFiltersDto) (*models.UserModel, error)
+	FindByIds(ctx context.Context, ids []int) ([]*models.UserModel, error)
+	Suggest(ctx context.Context, query *dtos.SuggestQueryDto, limit int) ([]*models.SuggestionModel, error)
+	Update(ctx context.Context, filter *dtos.UserFiltersDto, dto *dtos.UpdateUserDto, version *int) (*models.UserModel, error)
+	Patch(ctx context.Context, filter *dtos.UserFiltersDto, dto *dtos.PatchUserDto, version *int) (*models.UserModel, error)
+	Delete(ctx context.Context, filter *dtos.UserFiltersDto, version *int) (bool, error)
 }
 
 type userRepository struct {
-	pool *pgxpool.Pool
+	db     Querier
+	logger *slog.Logger
 }
 
-func NewUserRepository(p *pgxpool.Pool) UserRepository {
-	return &userRepository{pool: p}
+func NewUserRepository(db Querier, logger *slog.Logger) UserRepository {
+	return &userRepository{db: observing(translating(db), "users"), logger: logger}
 }
 
-func (u *userRepository) Find(filter *dtos.UserFiltersDto) (*models.UserModel, error) {
+func (u *userRepository) Find(ctx context.Context, filter *dtos.UserFiltersDto) (*models.UserModel, error) {
 	var usr models.UserModel
 	condition, args := u.buildFilters(filter, 0)
 
-	err := u.pool.QueryRow(context.Background(), fmt.Sprintf(FindUserSql, condition), args...).Scan(
+	err := u.db.QueryRow(ctx, fmt.Sprintf(FindUserSql, condition), args...).Scan(
 		&usr.Id,
 		&usr.Username,
 		&usr.DisplayName,
 		&usr.SocialId,
+		&usr.CreatedAt,
+		&usr.UpdatedAt,
+		&usr.Version,
 	)
 
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -56,53 +74,155 @@ func (u *userRepository) Find(filter *dtos.UserFiltersDto) (*models.UserModel, e
 	return &usr, nil
 }
 
-func (u *userRepository) Create(dto *dtos.UserDto) (*models.UserModel, error) {
+func (u *userRepository) FindByIds(ctx context.Context, ids []int) ([]*models.UserModel, error) {
+	rows, err := u.db.Query(ctx, FindUsersByIdsSql, ids)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var users []*models.UserModel = []*models.UserModel{}
+
+	defer rows.Close()
+	for rows.Next() {
+		var usr models.UserModel
+		err := rows.Scan(
+			&usr.Id,
+			&usr.Username,
+			&usr.DisplayName,
+			&usr.SocialId,
+			&usr.CreatedAt,
+			&usr.UpdatedAt,
+			&usr.Version,
+		)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, &usr)
+	}
+
+	return users, rows.Err()
+}
+
+func (u *userRepository) Suggest(ctx context.Context, query *dtos.SuggestQueryDto, limit int) ([]*models.SuggestionModel, error) {
+	text := ""
+	if query.Query != nil {
+		text = strings.TrimSpace(*query.Query)
+	}
+
+	rows, err := u.db.Query(ctx, SuggestUserSql, prefixPattern(text), text, limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.SuggestionModel, error) {
+		var suggestion models.SuggestionModel
+		err := row.Scan(&suggestion.Id, &suggestion.Label)
+		return &suggestion, err
+	})
+}
+
+func (u *userRepository) Create(ctx context.Context, dto *dtos.UserDto) (*models.UserModel, error) {
 	var usr models.UserModel
 
-	err := u.pool.QueryRow(context.Background(), CreateUserSql, &dto.Username, &dto.DisplayName, &dto.SocialId).Scan(
+	err := u.db.QueryRow(ctx, CreateUserSql, &dto.Username, &dto.DisplayName, &dto.SocialId).Scan(
 		&usr.Id,
 		&usr.Username,
 		&usr.DisplayName,
 		&usr.SocialId,
+		&usr.CreatedAt,
+		&usr.UpdatedAt,
+		&usr.Version,
 	)
 
 	if err != nil {
-		fmt.Println(err)
 		return nil, err
 	}
 
 	return &usr, nil
 }
 
-func (u *userRepository) Delete(filter *dtos.UserFiltersDto) (bool, error) {
+func (u *userRepository) Delete(ctx context.Context, filter *dtos.UserFiltersDto, version *int) (bool, error) {
 	condition, args := u.buildFilters(filter, 0)
+	condition, args = withVersion(condition, args, len(args)+1, version)
 
-	_, err := u.pool.Query(context.Background(), fmt.Sprintf(DeleteUserSql, condition), args...)
+	tag, err := u.db.Exec(ctx, fmt.Sprintf(DeleteUserSql, condition), args...)
 
 	if err != nil {
 		return false, err
 	}
 
-	return true, nil
+	return tag.RowsAffected() > 0, nil
 }
 
-func (u *userRepository) Update(filter *dtos.UserFiltersDto, dto *dtos.UpdateUserDto) (*models.UserModel, error) {
+func (u *userRepository) Update(ctx context.Context, filter *dtos.UserFiltersDto, dto *dtos.UpdateUserDto, version *int) (*models.UserModel, error) {
 	var usr models.UserModel
 	condition, args := u.buildFilters(filter, 2)
+	condition, args = withVersion(condition, args, 2+len(args)+1, version)
 
 	allArgs := make([]interface{}, 0, 2+len(args))
 	allArgs = append(allArgs, &dto.Username, &dto.DisplayName)
 	allArgs = append(allArgs, args...)
 
-	fmt.Println(UpdateUserSql + " " + condition)
+	u.logger.DebugContext(ctx, "Updating user", "condition", condition)
+
+	err := u.db.
+		QueryRow(ctx, fmt.Sprintf(UpdateUserSql, condition), allArgs...).
+		Scan(
+			&usr.Id,
+			&usr.Username,
+			&usr.DisplayName,
+			&usr.SocialId,
+			&usr.CreatedAt,
+			&usr.UpdatedAt,
+			&usr.Version,
+		)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &usr, nil
+}
+
+// Patch updates only the columns whose fields are set in dto.
+func (u *userRepository) Patch(ctx context.Context, filter *dtos.UserFiltersDto, dto *dtos.PatchUserDto, version *int) (*models.UserModel, error) {
+	var usr models.UserModel
+	var sets []string
+	var values []any
+
+	if dto.Username != nil {
+		values = append(values, *dto.Username)
+		sets = append(sets, fmt.Sprintf("username=$%d", len(values)))
+	}
+
+	if dto.DisplayName != nil {
+		values = append(values, *dto.DisplayName)
+		sets = append(sets, fmt.Sprintf("display_name=$%d", len(values)))
+	}
+
+	if len(sets) == 0 {
+		return u.Find(ctx, filter)
+	}
+
+	condition, args := u.buildFilters(filter, len(values))
+	condition, args = withVersion(condition, args, len(values)+len(args)+1, version)
 
-	err := u.pool.
-		QueryRow(context.Background(), fmt.Sprintf(UpdateUserSql, condition), allArgs...).
+	err := u.db.
+		QueryRow(ctx, fmt.Sprintf(PatchUserSql, strings.Join(sets, ", "), condition), append(values, args...)...).
 		Scan(
 			&usr.Id,
 			&usr.Username,
 			&usr.DisplayName,
 			&usr.SocialId,
+			&usr.CreatedAt,
+			&usr.UpdatedAt,
+			&usr.Version,
 		)
 
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -0,0 +1,24 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+)
+
+// withVersion narrows a WHERE condition to rows still at the expected version,
+// placing the version argument at the given position. Without a version the
+// condition is returned unchanged.
+func withVersion(condition string, args []any, position int, version *int) (string, []any) {
+	if version == nil {
+		return condition, args
+	}
+
+	clause := fmt.Sprintf("version=$%d", position)
+	args = append(args, *version)
+
+	if rest, ok := strings.CutPrefix(condition, "WHERE "); ok {
+		return fmt.Sprintf("WHERE (%s) AND %s", rest, clause), args
+	}
+
+	return "WHERE " + clause, args
+}
@@ -1,16 +1,10 @@
 package responses
 
-type BadRequestError struct {
-	Message string `json:"message"`
-}
+import (
+	"api/internal/enums"
+	"net/http"
+)
 
-func NewBadRequestError(err ...string) *BadRequestError {
-	if len(err) > 0 {
-		return &BadRequestError{
-			Message: err[0],
-		}
-	}
-	return &BadRequestError{
-		Message: "Bad Request",
-	}
+func NewBadRequestError(detail ...string) *Problem {
+	return NewProblem(http.StatusBadRequest, enums.ErrorBadRequest, detailOr(detail, "Bad Request"))
 }
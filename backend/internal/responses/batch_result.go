@@ -0,0 +1,36 @@
+package responses
+
+import (
+	"api/internal/enums"
+	"api/internal/models"
+)
+
+type BatchResult struct {
+	Mode    enums.BatchMode         `json:"mode"`
+	Success bool                    `json:"success"`
+	Results []*BatchOperationResult `json:"results"`
+}
+
+type BatchOperationResult struct {
+	Index  int                  `json:"index"`
+	Op     enums.BatchOperation `json:"op"`
+	Status int                  `json:"status"`
+	Item   *models.PasteModel   `json:"item,omitempty"`
+	Error  *Problem             `json:"error,omitempty"`
+}
+
+func NewBatchResult(mode enums.BatchMode, results []*BatchOperationResult) *BatchResult {
+	success := true
+	for _, result := range results {
+		if result.Error != nil {
+			success = false
+			break
+		}
+	}
+
+	return &BatchResult{
+		Mode:    mode,
+		Success: success,
+		Results: results,
+	}
+}
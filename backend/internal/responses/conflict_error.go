@@ -0,0 +1,10 @@
+package responses
+
+import (
+	"api/internal/enums"
+	"net/http"
+)
+
+func NewConflictError(detail ...string) *Problem {
+	return NewProblem(http.StatusConflict, enums.ErrorConflict, detailOr(detail, "Conflict"))
+}
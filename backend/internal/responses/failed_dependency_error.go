@@ -0,0 +1,10 @@
+package responses
+
+import (
+	"api/internal/enums"
+	"net/http"
+)
+
+func NewFailedDependencyError(detail ...string) *Problem {
+	return NewProblem(http.StatusFailedDependency, enums.ErrorFailedDependency, detailOr(detail, "Failed dependency"))
+}
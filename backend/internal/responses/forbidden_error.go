@@ -1,16 +1,10 @@
 package responses
 
-type ForbiddenError struct {
-	Message string `json:"message"`
-}
+import (
+	"api/internal/enums"
+	"net/http"
+)
 
-func NewForbiddenError(err ...string) *ForbiddenError {
-	if len(err) > 0 {
-		return &ForbiddenError{
-			Message: err[0],
-		}
-	}
-	return &ForbiddenError{
-		Message: "Access denied",
-	}
+func NewForbiddenError(detail ...string) *Problem {
+	return NewProblem(http.StatusForbidden, enums.ErrorForbidden, detailOr(detail, "Access denied"))
 }
@@ -0,0 +1,10 @@
+package responses
+
+import (
+	"api/internal/enums"
+	"net/http"
+)
+
+func NewGatewayTimeoutError(detail ...string) *Problem {
+	return NewProblem(http.StatusGatewayTimeout, enums.ErrorTimeout, detailOr(detail, "Request timed out"))
+}
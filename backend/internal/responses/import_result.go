@@ -0,0 +1,24 @@
+package responses
+
+type ImportResult struct {
+	Created     int            `json:"created"`
+	Overwritten int            `json:"overwritten"`
+	Renamed     int            `json:"renamed"`
+	Skipped     int            `json:"skipped"`
+	Failed      int            `json:"failed"`
+	Errors      []*ImportError `json:"errors"`
+}
+
+type ImportError struct {
+	Line  int      `json:"line"`
+	Error *Problem `json:"error"`
+}
+
+func NewImportResult() *ImportResult {
+	return &ImportResult{Errors: []*ImportError{}}
+}
+
+func (r *ImportResult) Fail(line int, err *Problem) {
+	r.Failed++
+	r.Errors = append(r.Errors, &ImportError{Line: line, Error: err})
+}
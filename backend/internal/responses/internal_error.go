@@ -1,16 +1,10 @@
 package responses
 
-type InternalError struct {
-	Message string `json:"message"`
-}
+import (
+	"api/internal/enums"
+	"net/http"
+)
 
-func NewInternalError(err ...string) *ForbiddenError {
-	if len(err) > 0 {
-		return &ForbiddenError{
-			Message: err[0],
-		}
-	}
-	return &ForbiddenError{
-		Message: "Internal server exception",
-	}
+func NewInternalError(detail ...string) *Problem {
+	return NewProblem(http.StatusInternalServerError, enums.ErrorInternal, detailOr(detail, "Internal server exception"))
 }
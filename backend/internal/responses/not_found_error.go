@@ -1,16 +1,10 @@
 package responses
 
-type NotFoundError struct {
-	Message string `json:"message"`
-}
+import (
+	"api/internal/enums"
+	"net/http"
+)
 
-func NewNotFoundError(err ...string) *NotFoundError {
-	if len(err) > 0 {
-		return &NotFoundError{
-			Message: err[0],
-		}
-	}
-	return &NotFoundError{
-		Message: "Not found",
-	}
+func NewNotFoundError(detail ...string) *Problem {
+	return NewProblem(http.StatusNotFound, enums.ErrorNotFound, detailOr(detail, "Not found"))
 }
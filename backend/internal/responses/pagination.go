@@ -1,8 +1,11 @@
 package responses
 
+import "api/internal/models"
+
 type PaginationResponse[T any] struct {
-	Items   *[]T `json:"items"`
-	HasNext bool  `json:"hasNext"`
+	Items   *[]T                            `json:"items"`
+	HasNext bool                            `json:"hasNext"`
+	Facets  map[string][]models.FacetBucket `json:"facets,omitempty"`
 }
 
 func NewPaginationResponse[T any](items *[]T, hasNext bool) *PaginationResponse[T] {
@@ -11,3 +14,8 @@ func NewPaginationResponse[T any](items *[]T, hasNext bool) *PaginationResponse[
 		HasNext: hasNext,
 	}
 }
+
+func (p *PaginationResponse[T]) WithFacets(facets map[string][]models.FacetBucket) *PaginationResponse[T] {
+	p.Facets = facets
+	return p
+}
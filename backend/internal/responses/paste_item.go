@@ -0,0 +1,15 @@
+package responses
+
+import "api/internal/models"
+
+type PasteItem struct {
+	*models.PasteModel
+	Snippet *string `json:"snippet,omitempty"`
+}
+
+func NewPasteItem(paste *models.PasteModel, snippet *string) *PasteItem {
+	return &PasteItem{
+		PasteModel: paste,
+		Snippet:    snippet,
+	}
+}
@@ -0,0 +1,10 @@
+package responses
+
+import (
+	"api/internal/enums"
+	"net/http"
+)
+
+func NewPreconditionFailedError(detail ...string) *Problem {
+	return NewProblem(http.StatusPreconditionFailed, enums.ErrorPreconditionFailed, detailOr(detail, "Precondition failed"))
+}
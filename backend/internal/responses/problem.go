@@ -0,0 +1,104 @@
+package responses
+
+import (
+	"api/internal/domain"
+	"api/internal/enums"
+	"context"
+	"errors"
+	"net/http"
+)
+
+const (
+	MIMEApplicationProblemJSON = "application/problem+json"
+	ProblemTypeDefault         = "about:blank"
+)
+
+// Problem is the body of every error response, an RFC 7807 problem details
+// object with the `code`, `violations` and `requestId` extensions. It is an
+// error itself, so handlers can just return it and leave the rendering to the
+// error handler.
+type Problem struct {
+	Type       string             `json:"type"`
+	Title      string             `json:"title"`
+	Status     int                `json:"status"`
+	Detail     string             `json:"detail,omitempty"`
+	Instance   string             `json:"instance,omitempty"`
+	Code       enums.ErrorCode    `json:"code"`
+	Violations []domain.Violation `json:"violations,omitempty"`
+	RequestId  string             `json:"requestId,omitempty"`
+}
+
+func NewProblem(status int, code enums.ErrorCode, detail string) *Problem {
+	return &Problem{
+		Type:   ProblemTypeDefault,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Detail
+	}
+	return p.Title
+}
+
+// detailOr returns the optional detail passed to a constructor or fallback.
+func detailOr(detail []string, fallback string) string {
+	if len(detail) > 0 {
+		return detail[0]
+	}
+	return fallback
+}
+
+// FromError presents an error returned by a service as a problem. Domain
+// errors keep their message and expired deadlines become a 504; anything
+// else becomes a 500 that does not leak the cause.
+func FromError(err error) *Problem {
+	var (
+		problem      *Problem
+		notFound     *domain.NotFoundError
+		conflict     *domain.ConflictError
+		validation   *domain.ValidationError
+		forbidden    *domain.ForbiddenError
+		precondition *domain.PreconditionFailedError
+		invalid      *domain.InvalidInputError
+		dependency   *domain.FailedDependencyError
+	)
+
+	switch {
+	case errors.As(err, &problem):
+		return problem
+	case errors.As(err, &notFound):
+		return NewNotFoundError(notFound.Message)
+	case errors.As(err, &conflict):
+		if len(conflict.Fields) == 0 {
+			return NewConflictError(conflict.Message)
+		}
+		result := NewProblem(http.StatusConflict, enums.ErrorAlreadyExists, conflict.Message)
+		for _, field := range conflict.Fields {
+			result.Violations = append(result.Violations, domain.NewViolation(conflict.Message, field))
+		}
+		return result
+	case errors.As(err, &validation):
+		return NewValidationError(validation.Message, validation.Violations)
+	case errors.As(err, &forbidden):
+		return NewForbiddenError(forbidden.Message)
+	case errors.As(err, &precondition):
+		return NewPreconditionFailedError(precondition.Message)
+	case errors.As(err, &invalid):
+		return NewBadRequestError(invalid.Message)
+	case errors.As(err, &dependency):
+		return NewFailedDependencyError(dependency.Message)
+	case errors.Is(err, domain.ErrUnsupportedMediaType):
+		return NewUnsupportedMediaTypeError()
+	case errors.Is(err, domain.ErrInvalidPatch):
+		return NewBadRequestError(err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return NewGatewayTimeoutError()
+	}
+
+	return NewInternalError()
+}
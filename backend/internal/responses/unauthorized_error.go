@@ -1,16 +1,10 @@
 package responses
 
-type UnauthorizedError struct {
-	Message string `json:"message"`
-}
+import (
+	"api/internal/enums"
+	"net/http"
+)
 
-func NewUnauthorizedError(err ...string) *UnauthorizedError {
-    if len(err) > 0 {
-        return &UnauthorizedError{
-            Message: err[0],
-        }
-    }
-    return &UnauthorizedError{
-        Message: "Unauthorized",
-    }
+func NewUnauthorizedError(detail ...string) *Problem {
+	return NewProblem(http.StatusUnauthorized, enums.ErrorUnauthorized, detailOr(detail, "Unauthorized"))
 }
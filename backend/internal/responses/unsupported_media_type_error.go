@@ -0,0 +1,10 @@
+package responses
+
+import (
+	"api/internal/enums"
+	"net/http"
+)
+
+func NewUnsupportedMediaTypeError(detail ...string) *Problem {
+	return NewProblem(http.StatusUnsupportedMediaType, enums.ErrorUnsupportedMediaType, detailOr(detail, "Unsupported Content-Type"))
+}
@@ -1,25 +1,13 @@
 package responses
 
-type Violation struct {
-	Message      string `json:"message"`
-	PropertyPath string `json:"propertyPath"`
-}
-
-type ValidationError struct {
-	Message    string      `json:"message"`
-	Violations []Violation `json:"violations"`
-}
-
-func NewValidationError(message string, violations []Violation) *ValidationError {
-	return &ValidationError{
-		Message:    message,
-		Violations: violations,
-	}
-}
-
-func NewViolation(message string, propertyPath string) *Violation {
-	return &Violation{
-		Message:      message,
-		PropertyPath: propertyPath,
-	}
+import (
+	"api/internal/domain"
+	"api/internal/enums"
+	"net/http"
+)
+
+func NewValidationError(detail string, violations []domain.Violation) *Problem {
+	problem := NewProblem(http.StatusUnprocessableEntity, enums.ErrorValidationFailed, detail)
+	problem.Violations = violations
+	return problem
 }
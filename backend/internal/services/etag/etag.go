@@ -0,0 +1,42 @@
+package etag
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Format returns the entity tag of a resource at the given version.
+func Format(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// MatchesStrong reports whether an If-Match header value lists the tag of the
+// given version. "*" matches any version; weak tags (W/"1") never match, as
+// RFC 9110 requires strong comparison for If-Match.
+func MatchesStrong(header string, version int) bool {
+	return matches(header, version, false)
+}
+
+// MatchesWeak reports whether an If-None-Match header value lists the tag of
+// the given version. "*" matches any version and weak tags are compared by
+// their opaque part.
+func MatchesWeak(header string, version int) bool {
+	return matches(header, version, true)
+}
+
+func matches(header string, version int, weak bool) bool {
+	tag := Format(version)
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+
+	return false
+}
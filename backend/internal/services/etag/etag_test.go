@@ -0,0 +1,40 @@
+package etag
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	if got := Format(3); got != `"3"` {
+		t.Errorf(`Format(3) = %s, want "3"`, got)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		version int
+		strong  bool
+		weak    bool
+	}{
+		{name: "strong", header: `"3"`, version: 3, strong: true, weak: true},
+		{name: "other version", header: `"2"`, version: 3, strong: false, weak: false},
+		{name: "weak", header: `W/"3"`, version: 3, strong: false, weak: true},
+		{name: "any", header: "*", version: 7, strong: true, weak: true},
+		{name: "list", header: `"1", "2" ,"3"`, version: 2, strong: true, weak: true},
+		{name: "list with a weak tag", header: `"1", W/"2"`, version: 2, strong: false, weak: true},
+		{name: "list without the version", header: `"1", "2"`, version: 3, strong: false, weak: false},
+		{name: "unquoted", header: "3", version: 3, strong: false, weak: false},
+		{name: "empty", header: "", version: 3, strong: false, weak: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchesStrong(tt.header, tt.version); got != tt.strong {
+				t.Errorf("MatchesStrong(%q, %d) = %v, want %v", tt.header, tt.version, got, tt.strong)
+			}
+			if got := MatchesWeak(tt.header, tt.version); got != tt.weak {
+				t.Errorf("MatchesWeak(%q, %d) = %v, want %v", tt.header, tt.version, got, tt.weak)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package fieldset
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Parse splits a comma separated list of fields, trimming blanks.
+// An empty string yields nil, which means "all fields".
+func Parse(raw string) []string {
+	var fields []string
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if field != "" && !slices.Contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// Of returns the json names of all fields of T, including promoted fields
+// of embedded structs.
+func Of[T any]() []string {
+	return namesOf(reflect.TypeFor[T]())
+}
+
+func namesOf(t reflect.Type) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+
+		if field.Anonymous && name == "" {
+			names = append(names, namesOf(field.Type)...)
+			continue
+		}
+
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// Unknown returns the requested fields that are not in allowed.
+func Unknown(fields []string, allowed []string) []string {
+	var unknown []string
+	for _, field := range fields {
+		if !slices.Contains(allowed, field) {
+			unknown = append(unknown, field)
+		}
+	}
+	return unknown
+}
+
+// Project encodes value as a JSON object and keeps only the given fields.
+// With no fields the whole object is kept.
+func Project(value any, fields []string) (map[string]any, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var object map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&object); err != nil {
+		return nil, err
+	}
+
+	if len(fields) == 0 {
+		return object, nil
+	}
+
+	for key := range object {
+		if !slices.Contains(fields, key) {
+			delete(object, key)
+		}
+	}
+	return object, nil
+}
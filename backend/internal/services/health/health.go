@@ -0,0 +1,91 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Check returns nil when the dependency it checks is usable.
+type Check func(ctx context.Context) error
+
+// Result is the outcome of a single check.
+type Result struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is up only when every check is.
+type Report struct {
+	Status string             `json:"status"`
+	Checks map[string]*Result `json:"checks"`
+}
+
+type named struct {
+	name  string
+	check Check
+}
+
+// Checker runs the registered checks concurrently, each bounded by timeout.
+type Checker struct {
+	checks  []named
+	timeout time.Duration
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+func (c *Checker) Add(name string, check Check) {
+	c.checks = append(c.checks, named{name: name, check: check})
+}
+
+func (c *Checker) Run(ctx context.Context) *Report {
+	report := &Report{Status: StatusUp, Checks: make(map[string]*Result, len(c.checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result := run(ctx, check.check, c.timeout)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Checks[check.name] = result
+			if result.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return report
+}
+
+func run(ctx context.Context, check Check, timeout time.Duration) *Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	started := time.Now()
+	err := check(ctx)
+	result := &Result{Status: StatusUp, LatencyMs: float64(time.Since(started).Microseconds()) / 1000}
+
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
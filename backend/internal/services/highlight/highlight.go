@@ -0,0 +1,202 @@
+package highlight
+
+import (
+	"api/internal/enums"
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	DefaultLength = 120
+	ellipsis      = "…"
+)
+
+// Options describes how a snippet is cut and decorated.
+type Options struct {
+	Length int
+	Start  string
+	Stop   string
+	Escape func(string) string
+}
+
+// NewOptions returns options for the given snippet length and marker style.
+// A zero length falls back to DefaultLength, an empty marker to discord.
+func NewOptions(length int, marker enums.HighlightMarker) Options {
+	if length <= 0 {
+		length = DefaultLength
+	}
+
+	switch marker {
+	case enums.HighlightHtml:
+		return Options{Length: length, Start: "<mark>", Stop: "</mark>", Escape: html.EscapeString}
+	case enums.HighlightNone:
+		return Options{Length: length}
+	default:
+		return Options{Length: length, Start: "**", Stop: "**", Escape: escapeMarkdown}
+	}
+}
+
+// Terms splits the search string into lowercase terms, dropping duplicates.
+func Terms(search string) []string {
+	var terms []string
+	seen := map[string]bool{}
+
+	for _, term := range strings.Fields(strings.ToLower(search)) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+		terms = append(terms, term)
+	}
+
+	return terms
+}
+
+// Snippet cuts a window of at most opts.Length runes around the first match
+// of any term in text and wraps every match inside the window with markers.
+// If nothing matches, the beginning of the text is returned.
+func Snippet(text string, terms []string, opts Options) string {
+	runes := []rune(text)
+	lower := toLowerRunes(runes)
+	matches := findMatches(lower, terms)
+
+	start, end := window(runes, matches, opts.Length)
+
+	var builder strings.Builder
+	if start > 0 {
+		builder.WriteString(ellipsis)
+	}
+
+	position := start
+	for _, m := range matches {
+		if m.end <= start || m.start >= end {
+			continue
+		}
+		from, to := max(m.start, start), min(m.end, end)
+		builder.WriteString(escape(string(runes[position:from]), opts))
+		builder.WriteString(opts.Start)
+		builder.WriteString(escape(string(runes[from:to]), opts))
+		builder.WriteString(opts.Stop)
+		position = to
+	}
+	builder.WriteString(escape(string(runes[position:end]), opts))
+
+	if end < len(runes) {
+		builder.WriteString(ellipsis)
+	}
+
+	return builder.String()
+}
+
+type match struct {
+	start int
+	end   int
+}
+
+// findMatches returns non-overlapping matches of terms ordered by position,
+// preferring the longest term when several start at the same rune.
+func findMatches(lower []rune, terms []string) []match {
+	var matches []match
+	needles := make([][]rune, 0, len(terms))
+	for _, term := range terms {
+		needles = append(needles, toLowerRunes([]rune(term)))
+	}
+
+	for i := 0; i < len(lower); {
+		longest := 0
+		for _, needle := range needles {
+			if len(needle) > longest && hasPrefixAt(lower, needle, i) {
+				longest = len(needle)
+			}
+		}
+
+		if longest == 0 {
+			i++
+			continue
+		}
+
+		matches = append(matches, match{start: i, end: i + longest})
+		i += longest
+	}
+
+	return matches
+}
+
+// window picks the [start, end) rune range of the snippet, leaving a quarter
+// of the length as leading context and snapping the edges to word boundaries.
+func window(runes []rune, matches []match, length int) (int, int) {
+	if len(runes) <= length {
+		return 0, len(runes)
+	}
+
+	start := 0
+	if len(matches) > 0 {
+		start = max(0, matches[0].start-length/4)
+	}
+	end := min(len(runes), start+length)
+	if end == len(runes) {
+		start = max(0, end-length)
+	}
+
+	if start > 0 {
+		for i := start; i < end && i < start+length/4; i++ {
+			if unicode.IsSpace(runes[i-1]) {
+				start = i
+				break
+			}
+		}
+	}
+
+	if end < len(runes) {
+		for i := end; i > start+length*3/4; i-- {
+			if unicode.IsSpace(runes[i]) {
+				end = i
+				break
+			}
+		}
+	}
+
+	return start, end
+}
+
+func hasPrefixAt(haystack []rune, needle []rune, at int) bool {
+	if at+len(needle) > len(haystack) {
+		return false
+	}
+	for i, r := range needle {
+		if haystack[at+i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+func toLowerRunes(runes []rune) []rune {
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	return lower
+}
+
+func escape(text string, opts Options) string {
+	if opts.Escape == nil {
+		return text
+	}
+	return opts.Escape(text)
+}
+
+var markdownReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	"*", `\*`,
+	"_", `\_`,
+	"~", `\~`,
+	"|", `\|`,
+	"`", "\\`",
+)
+
+// escapeMarkdown escapes Discord markdown so the paste cannot break the markers.
+func escapeMarkdown(text string) string {
+	return markdownReplacer.Replace(text)
+}
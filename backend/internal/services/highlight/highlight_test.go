@@ -0,0 +1,125 @@
+package highlight
+
+import (
+	"api/internal/enums"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTerms(t *testing.T) {
+	tests := []struct {
+		name   string
+		search string
+		want   []string
+	}{
+		{name: "empty", search: "", want: nil},
+		{name: "lowercase", search: "Hello World", want: []string{"hello", "world"}},
+		{name: "duplicates", search: "go GO  go", want: []string{"go"}},
+		{name: "cyrillic", search: "Привет мир", want: []string{"привет", "мир"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Terms(tt.search); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Terms(%q) = %q, want %q", tt.search, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	long := strings.Repeat("lorem ", 20) + "needle " + strings.Repeat("ipsum ", 20)
+
+	tests := []struct {
+		name  string
+		text  string
+		terms []string
+		opts  Options
+		want  string
+	}{
+		{
+			name:  "short text is marked whole",
+			text:  "Find the Needle here",
+			terms: []string{"needle"},
+			opts:  NewOptions(0, enums.HighlightDiscord),
+			want:  "Find the **Needle** here",
+		},
+		{
+			name:  "every match is marked",
+			text:  "go and go",
+			terms: []string{"go"},
+			opts:  NewOptions(0, enums.HighlightHtml),
+			want:  "<mark>go</mark> and <mark>go</mark>",
+		},
+		{
+			name:  "longest term wins at the same position",
+			text:  "gopher",
+			terms: []string{"go", "gopher"},
+			opts:  NewOptions(0, enums.HighlightDiscord),
+			want:  "**gopher**",
+		},
+		{
+			name:  "no match keeps the text",
+			text:  "nothing here",
+			terms: []string{"needle"},
+			opts:  NewOptions(0, enums.HighlightDiscord),
+			want:  "nothing here",
+		},
+		{
+			name:  "no markers",
+			text:  "a needle",
+			terms: []string{"needle"},
+			opts:  NewOptions(0, enums.HighlightNone),
+			want:  "a needle",
+		},
+		{
+			name:  "markdown is escaped around markers",
+			text:  "*bold* needle_x",
+			terms: []string{"needle"},
+			opts:  NewOptions(0, enums.HighlightDiscord),
+			want:  `\*bold\* **needle**\_x`,
+		},
+		{
+			name:  "html is escaped around markers",
+			text:  "<b>needle</b>",
+			terms: []string{"needle"},
+			opts:  NewOptions(0, enums.HighlightHtml),
+			want:  "&lt;b&gt;<mark>needle</mark>&lt;/b&gt;",
+		},
+		{
+			name:  "window around the match snaps to words",
+			text:  long,
+			terms: []string{"needle"},
+			opts:  NewOptions(40, enums.HighlightDiscord),
+			want:  "…lorem **needle** ipsum ipsum ipsum ipsum…",
+		},
+		{
+			name:  "window without a match starts at the beginning",
+			text:  long,
+			terms: []string{"absent"},
+			opts:  NewOptions(20, enums.HighlightNone),
+			want:  "lorem lorem lorem…",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Snippet(tt.text, tt.terms, tt.opts); got != tt.want {
+				t.Errorf("Snippet() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnippetLength(t *testing.T) {
+	text := strings.Repeat("слово ", 100)
+	opts := NewOptions(50, enums.HighlightNone)
+
+	got := []rune(Snippet(text, []string{"слово"}, opts))
+
+	// The window itself fits the length, the ellipses come on top.
+	if len(got) > opts.Length+2 {
+		t.Errorf("snippet has %d runes, want at most %d", len(got), opts.Length+2)
+	}
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"api/internal/domain"
+	"api/internal/dtos"
+	"api/internal/enums"
+	"api/internal/metrics"
+	"api/internal/models"
+	"api/internal/repositories"
+	"api/internal/services/validators"
+	"context"
+	"encoding/json"
+)
+
+// BatchOutcome is the result of a single batch operation; Err is nil when the
+// operation took effect.
+type BatchOutcome struct {
+	Op   enums.BatchOperation
+	Item *models.PasteModel
+	Err  error
+}
+
+type BatchReport struct {
+	Mode     enums.BatchMode
+	Outcomes []*BatchOutcome
+	// Failed is the operation that rolled an atomic batch back.
+	Failed *BatchOutcome
+}
+
+// Batch runs several create/update/delete operations at once. In the `atomic`
+// mode (default) they share a transaction and the first failure rolls
+// everything back; in the `bestEffort` mode every operation stands on its own.
+// Either way the report carries an outcome per operation.
+func (p *pasteService) Batch(ctx context.Context, batch *dtos.BatchPastesDto) (*BatchReport, error) {
+	if violations := validators.Validate(ctx, batch); violations != nil {
+		return nil, violations
+	}
+
+	report := &BatchReport{
+		Mode:     enums.BatchAtomic,
+		Outcomes: make([]*BatchOutcome, len(batch.Operations)),
+	}
+
+	if batch.Mode != nil {
+		report.Mode = *batch.Mode
+	}
+
+	var err error
+	if report.Mode == enums.BatchBestEffort {
+		report, err = p.batchBestEffort(ctx, batch.Operations, report)
+	} else {
+		report, err = p.batchAtomic(ctx, batch.Operations, report)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	failed := 0
+	for _, outcome := range report.Outcomes {
+		if outcome.Err != nil {
+			failed++
+		}
+	}
+
+	p.logger.InfoContext(ctx, "Batch applied", "mode", report.Mode, "operations", len(report.Outcomes), "failed", failed)
+
+	return report, nil
+}
+
+func (p *pasteService) batchAtomic(ctx context.Context, operations []dtos.BatchPasteOperationDto, report *BatchReport) (*BatchReport, error) {
+	failed := -1
+
+	err := p.uow.Do(ctx, func(r *repositories.Repositories) error {
+		failed = -1
+		for i := range operations {
+			outcome := p.runOperation(ctx, r, &operations[i])
+			report.Outcomes[i] = outcome
+			if outcome.Err != nil {
+				failed = i
+				return outcome.Err
+			}
+		}
+		return nil
+	})
+
+	if err != nil && (failed < 0 || !domain.IsClientError(err)) {
+		return nil, err
+	}
+
+	if err != nil {
+		for i := range operations {
+			switch {
+			case i < failed:
+				report.Outcomes[i].Item = nil
+				report.Outcomes[i].Err = domain.ErrRolledBack
+			case i > failed:
+				report.Outcomes[i] = &BatchOutcome{Op: operations[i].Op, Err: domain.ErrNotExecuted}
+			}
+		}
+
+		report.Failed = report.Outcomes[failed]
+		return report, nil
+	}
+
+	countOutcomes(report.Outcomes)
+	p.suggestions.Purge()
+
+	return report, nil
+}
+
+// batchBestEffort runs every operation in a unit of work of its own.
+func (p *pasteService) batchBestEffort(ctx context.Context, operations []dtos.BatchPasteOperationDto, report *BatchReport) (*BatchReport, error) {
+	changed := false
+
+	for i := range operations {
+		outcome := &BatchOutcome{Op: operations[i].Op}
+
+		err := p.uow.Do(ctx, func(r *repositories.Repositories) error {
+			outcome = p.runOperation(ctx, r, &operations[i])
+			return outcome.Err
+		})
+
+		if err != nil {
+			outcome.Item = nil
+			outcome.Err = err
+		}
+
+		changed = changed || outcome.Err == nil
+		report.Outcomes[i] = outcome
+	}
+
+	if changed {
+		countOutcomes(report.Outcomes)
+		p.suggestions.Purge()
+	}
+
+	return report, nil
+}
+
+// countOutcomes counts the pastes created and deleted by committed operations.
+func countOutcomes(outcomes []*BatchOutcome) {
+	for _, outcome := range outcomes {
+		if outcome.Err != nil {
+			continue
+		}
+
+		switch outcome.Op {
+		case enums.BatchCreate:
+			metrics.PastesCreated.Inc()
+		case enums.BatchDelete:
+			metrics.PastesDeleted.Inc()
+		}
+	}
+}
+
+// runOperation executes a single batch operation against r.
+func (p *pasteService) runOperation(ctx context.Context, r *repositories.Repositories, op *dtos.BatchPasteOperationDto) *BatchOutcome {
+	outcome := &BatchOutcome{Op: op.Op}
+
+	switch op.Op {
+	case enums.BatchCreate:
+		var data dtos.PasteDto
+		if outcome.Err = p.decodeOperationData(op, &data); outcome.Err == nil {
+			outcome.Item, outcome.Err = p.createPaste(ctx, r, &data)
+		}
+	case enums.BatchUpdate:
+		var data dtos.UpdatePasteDto
+		if outcome.Err = p.decodeOperationData(op, &data); outcome.Err == nil {
+			outcome.Item, outcome.Err = p.updatePaste(ctx, r, *op.Id, &data, VersionPrecondition(op.Version))
+		}
+	case enums.BatchDelete:
+		outcome.Err = p.deletePaste(ctx, r, *op.Id, VersionPrecondition(op.Version))
+	}
+
+	return outcome
+}
+
+func (p *pasteService) decodeOperationData(op *dtos.BatchPasteOperationDto, data any) error {
+	if err := json.Unmarshal(op.Data, data); err != nil {
+		return domain.NewInvalidInputError("Cannot parse operation data")
+	}
+	return nil
+}
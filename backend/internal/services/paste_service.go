@@ -1,206 +1,598 @@
 package services
 
 import (
+	"api/internal/domain"
 	"api/internal/dtos"
+	"api/internal/enums"
+	"api/internal/metrics"
+	"api/internal/models"
 	"api/internal/repositories"
 	"api/internal/responses"
-	"api/internal/services/querymap"
+	"api/internal/services/cache"
+	"api/internal/services/fieldset"
+	"api/internal/services/highlight"
+	"api/internal/services/patch"
+	"api/internal/services/transfer"
 	"api/internal/services/validators"
-
-	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/log"
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
 )
 
 type PasteService interface {
-	Create(c *fiber.Ctx) error
-	Find(c *fiber.Ctx) error
-	Search(c *fiber.Ctx) error
-	Update(c *fiber.Ctx) error
-	Delete(c *fiber.Ctx) error
+	Find(ctx context.Context, filter *dtos.PastesFilterDto, shape *dtos.ResponseShapeDto) (*PasteView, error)
+	FindOne(ctx context.Context, id int, shape *dtos.ResponseShapeDto) (*PasteView, error)
+	Search(ctx context.Context, query *dtos.PastesSearchQueryDto, shape *dtos.ResponseShapeDto) (*responses.PaginationResponse[any], error)
+	Suggest(ctx context.Context, query *dtos.SuggestQueryDto) ([]*models.SuggestionModel, error)
+	Create(ctx context.Context, dto *dtos.PasteDto) (*models.PasteModel, error)
+	Update(ctx context.Context, id int, dto *dtos.UpdatePasteDto, precondition Precondition) (*models.PasteModel, error)
+	Patch(ctx context.Context, id int, document []byte, contentType string, precondition Precondition) (*models.PasteModel, error)
+	Delete(ctx context.Context, id int, precondition Precondition) error
+	Batch(ctx context.Context, batch *dtos.BatchPastesDto) (*BatchReport, error)
+	Export(ctx context.Context, filter *dtos.PastesFilterDto, fn func(record *models.PasteRecordModel) error) error
+	Import(ctx context.Context, reader transfer.Reader, strategy enums.ConflictStrategy) (*ImportReport, error)
+}
+
+// PasteView is a paste together with the body it is presented as, which is
+// the paste itself unless a response shape was requested.
+type PasteView struct {
+	Paste *models.PasteModel
+	Body  any
 }
 
 type pasteService struct {
+	uow             repositories.UnitOfWork
 	pasteRepository repositories.PasteRepository
+	userRepository  repositories.UserRepository
+	suggestions     *cache.Cache[string, []*models.SuggestionModel]
+	logger          *slog.Logger
 }
 
-func NewPasteService(r repositories.PasteRepository) PasteService {
-	return &pasteService{pasteRepository: r}
+func NewPasteService(uow repositories.UnitOfWork, r repositories.PasteRepository, u repositories.UserRepository, logger *slog.Logger) PasteService {
+	return &tracingPasteService{next: &pasteService{
+		uow:             uow,
+		pasteRepository: r,
+		userRepository:  u,
+		suggestions:     cache.New[string, []*models.SuggestionModel](suggestCacheSize, suggestCacheTTL),
+		logger:          logger,
+	}}
 }
 
-func (p *pasteService) Create(c *fiber.Ctx) error {
-	var body dtos.PasteDto
+func (p *pasteService) Create(ctx context.Context, dto *dtos.PasteDto) (*models.PasteModel, error) {
+	newPaste, err := within(ctx, p.uow, func(r *repositories.Repositories) (*models.PasteModel, error) {
+		return p.createPaste(ctx, r, dto)
+	})
 
-	if err := c.BodyParser(&body); err != nil {
-		log.Error(err)
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.NewInternalError())
+	if err != nil {
+		return nil, err
 	}
 
-	violations := validators.AppValidatorInstance.Validate(body)
+	metrics.PastesCreated.Inc()
+	p.suggestions.Purge()
+	p.logger.InfoContext(ctx, "Paste created", "id", newPaste.Id)
 
-	if violations != nil {
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(violations)
+	return newPaste, nil
+}
+
+func (p *pasteService) Delete(ctx context.Context, id int, precondition Precondition) error {
+	err := p.uow.Do(ctx, func(r *repositories.Repositories) error {
+		return p.deletePaste(ctx, r, id, precondition)
+	})
+
+	if err != nil {
+		return err
 	}
 
-	strict := true
-	existed, err := p.pasteRepository.FindOne(&dtos.PastesFilterDto{
-		Search: &body.Title,
-		Strict: &strict,
-	}, nil)
+	metrics.PastesDeleted.Inc()
+	p.suggestions.Purge()
+	p.logger.InfoContext(ctx, "Paste deleted", "id", id)
+
+	return nil
+}
+
+func (p *pasteService) Find(ctx context.Context, filter *dtos.PastesFilterDto, shape *dtos.ResponseShapeDto) (*PasteView, error) {
+	if violations := validators.Validate(ctx, filter); violations != nil {
+		return nil, violations
+	}
 
+	parsedShape, err := p.parseShape(ctx, shape)
 	if err != nil {
-		log.Errorf("While quering db %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.NewInternalError())
+		return nil, err
 	}
 
-	if existed != nil {
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(responses.NewValidationError("Paste already exists", []responses.Violation{
-			*responses.NewViolation("Paste already exists", "title"),
-		}))
+	existed, err := p.pasteRepository.FindOne(ctx, filter, nil)
+
+	if err != nil {
+		return nil, err
 	}
 
-	newPaste, err := p.pasteRepository.Create(&body)
+	if existed == nil {
+		return nil, domain.NewNotFoundError("Paste not found")
+	}
+
+	if parsedShape.isEmpty() {
+		return &PasteView{Paste: existed, Body: existed}, nil
+	}
+
+	items, err := p.buildItems(ctx, []*models.PasteModel{existed}, parsedShape, nil)
 
 	if err != nil {
-		log.Errorf("While quering db %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.NewInternalError())
+		return nil, err
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(newPaste)
+	return &PasteView{Paste: existed, Body: items[0]}, nil
 }
 
-func (p *pasteService) Delete(c *fiber.Ctx) error {
-	queryObj, err := querymap.FromURLStringToStruct[dtos.PastesFilterDto](c.BaseURL() + c.OriginalURL())
+func (p *pasteService) FindOne(ctx context.Context, id int, shape *dtos.ResponseShapeDto) (*PasteView, error) {
+	return p.Find(ctx, &dtos.PastesFilterDto{PasteId: &id}, shape)
+}
+
+// Search runs a search query; shape trims the items down to the requested
+// fields and relations.
+func (p *pasteService) Search(ctx context.Context, query *dtos.PastesSearchQueryDto, shape *dtos.ResponseShapeDto) (*responses.PaginationResponse[any], error) {
+	if violations := validators.Validate(ctx, query); violations != nil {
+		return nil, violations
+	}
 
+	parsedShape, err := p.parseShape(ctx, shape)
 	if err != nil {
-		log.Error(err)
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.NewInternalError("Failed to parse query..."))
+		return nil, err
 	}
 
-	if p.isEmptyFilter(queryObj) {
-		return c.Status(fiber.StatusBadRequest).JSON(responses.NewBadRequestError("Empty query parametrs"))
+	var facets []enums.Facet
+	if query.Facets != nil {
+		names := fieldset.Parse(*query.Facets)
+		allowed := make([]string, 0, len(enums.Facets))
+		for _, facet := range enums.Facets {
+			allowed = append(allowed, string(facet))
+		}
+
+		if unknown := fieldset.Unknown(names, allowed); len(unknown) > 0 {
+			return nil, domain.NewValidationError("Invalid payload",
+				domain.NewViolation("Unknown facets: "+strings.Join(unknown, ", "), "facets"))
+		}
+
+		for _, name := range names {
+			facets = append(facets, enums.Facet(name))
+		}
 	}
 
-	existed, err := p.pasteRepository.FindOne(queryObj, nil)
+	existed, err := p.pasteRepository.FindMany(ctx, query.Filter, query.Pagination)
+
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.PasteSearches.Inc()
+	if len(existed) == 0 {
+		metrics.PasteSearchesEmpty.Inc()
+	}
 
 	if existed == nil {
-		return c.Status(fiber.StatusNotFound).JSON(responses.NewBadRequestError("Paste not found"))
+		return nil, domain.NewNotFoundError("Paste not found")
+	}
+
+	var limit int = 10
+
+	if query.Pagination != nil && query.Pagination.Limit != nil {
+		limit = *query.Pagination.Limit
+	}
+
+	var facetBuckets map[string][]models.FacetBucket
+	if len(facets) > 0 {
+		buckets, err := p.pasteRepository.Facets(ctx, query.Filter, facets)
+
+		if err != nil {
+			return nil, err
+		}
+
+		facetBuckets = make(map[string][]models.FacetBucket, len(buckets))
+		for facet, bucket := range buckets {
+			facetBuckets[string(facet)] = bucket
+		}
+	}
+
+	items := make([]any, 0, len(existed))
+
+	if query.Snippet == nil && parsedShape.isEmpty() {
+		for _, paste := range existed {
+			items = append(items, paste)
+		}
+		return responses.NewPaginationResponse(&items, len(existed) > limit).WithFacets(facetBuckets), nil
 	}
 
-	_, err = p.pasteRepository.Delete(queryObj)
+	shaped, err := p.buildItems(ctx, existed, parsedShape, p.snippetBuilder(query))
 
 	if err != nil {
-		log.Error(err)
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.NewInternalError("Error while quering db..."))
+		return nil, err
+	}
+
+	for _, item := range shaped {
+		items = append(items, item)
 	}
 
-	return c.SendStatus(fiber.StatusNoContent)
+	return responses.NewPaginationResponse(&items, len(existed) > limit).WithFacets(facetBuckets), nil
 }
 
-func (p *pasteService) Find(c *fiber.Ctx) error {
-	url := c.BaseURL() + c.OriginalURL()
-	queryObj, err := querymap.FromURLStringToStruct[dtos.PastesFilterDto](url)
-	if err != nil {
-		log.Error(err)
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.NewInternalError("Failed to parse query..."))
+// pasteShape describes which fields of a paste response are returned
+// and which relations are embedded into it.
+type pasteShape struct {
+	pasteFields   []string
+	userFields    []string
+	includeAuthor bool
+}
+
+func (s *pasteShape) isEmpty() bool {
+	return s.pasteFields == nil && s.userFields == nil && !s.includeAuthor
+}
+
+// parseShape checks `fields[pastes]`, `fields[users]` and `include`; a nil
+// shape means the full paste.
+func (p *pasteService) parseShape(ctx context.Context, shapeObj *dtos.ResponseShapeDto) (*pasteShape, error) {
+	if shapeObj == nil {
+		return &pasteShape{}, nil
+	}
+
+	if violations := validators.Validate(ctx, shapeObj); violations != nil {
+		return nil, violations
+	}
+
+	shape := &pasteShape{
+		pasteFields: fieldset.Parse(shapeObj.Fields[enums.ResourcePastes]),
+		userFields:  fieldset.Parse(shapeObj.Fields[enums.ResourceUsers]),
+	}
+
+	var violations []domain.Violation
+	if unknown := fieldset.Unknown(shape.pasteFields, fieldset.Of[responses.PasteItem]()); len(unknown) > 0 {
+		violations = append(violations, domain.NewViolation("Unknown fields: "+strings.Join(unknown, ", "), "fields[pastes]"))
+	}
+
+	if unknown := fieldset.Unknown(shape.userFields, fieldset.Of[models.UserModel]()); len(unknown) > 0 {
+		violations = append(violations, domain.NewViolation("Unknown fields: "+strings.Join(unknown, ", "), "fields[users]"))
+	}
+
+	if shapeObj.Include != nil {
+		include := fieldset.Parse(*shapeObj.Include)
+		if unknown := fieldset.Unknown(include, []string{enums.IncludeAuthor}); len(unknown) > 0 {
+			violations = append(violations, domain.NewViolation("Unknown relations: "+strings.Join(unknown, ", "), "include"))
+		}
+		shape.includeAuthor = slices.Contains(include, enums.IncludeAuthor)
+	}
+
+	if violations != nil {
+		return nil, domain.NewValidationError("Invalid payload", violations...)
+	}
+
+	return shape, nil
+}
+
+// snippetBuilder returns a function producing highlighted snippets for the
+// search query, or nil if no snippet was requested.
+func (p *pasteService) snippetBuilder(queryObj *dtos.PastesSearchQueryDto) func(paste *models.PasteModel) *string {
+	if queryObj.Snippet == nil {
+		return nil
+	}
+
+	var terms []string
+	if queryObj.Filter != nil && queryObj.Filter.Search != nil {
+		terms = highlight.Terms(*queryObj.Filter.Search)
+	}
+
+	length := 0
+	if queryObj.Snippet.Length != nil {
+		length = *queryObj.Snippet.Length
+	}
+	marker := enums.HighlightDiscord
+	if queryObj.Snippet.Highlight != nil {
+		marker = *queryObj.Snippet.Highlight
+	}
+	opts := highlight.NewOptions(length, marker)
+
+	return func(paste *models.PasteModel) *string {
+		text := highlight.Snippet(paste.Paste, terms, opts)
+		return &text
+	}
+}
+
+// buildItems trims the pastes down to the requested fields, attaches snippets
+// and embeds the authors, loading all of them with a single query.
+func (p *pasteService) buildItems(ctx context.Context, pastes []*models.PasteModel, shape *pasteShape, snippet func(paste *models.PasteModel) *string) ([]map[string]any, error) {
+	authors := map[int]*models.UserModel{}
+
+	if shape.includeAuthor && len(pastes) > 0 {
+		var ids []int
+		for _, paste := range pastes {
+			if !slices.Contains(ids, paste.UserId) {
+				ids = append(ids, paste.UserId)
+			}
+		}
+
+		users, err := p.userRepository.FindByIds(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, usr := range users {
+			authors[usr.Id] = usr
+		}
+	}
+
+	items := make([]map[string]any, 0, len(pastes))
+	for _, paste := range pastes {
+		var text *string
+		if snippet != nil {
+			text = snippet(paste)
+		}
+
+		item, err := fieldset.Project(responses.NewPasteItem(paste, text), shape.pasteFields)
+		if err != nil {
+			return nil, err
+		}
+
+		if shape.includeAuthor {
+			item[enums.IncludeAuthor] = nil
+			if author, ok := authors[paste.UserId]; ok {
+				if item[enums.IncludeAuthor], err = fieldset.Project(author, shape.userFields); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// Suggest matches the query against paste titles, by prefix or similarity.
+func (p *pasteService) Suggest(ctx context.Context, query *dtos.SuggestQueryDto) ([]*models.SuggestionModel, error) {
+	if violations := validators.Validate(ctx, query); violations != nil {
+		return nil, violations
 	}
-	existed, err := p.pasteRepository.FindOne(queryObj, nil)
 
+	key := suggestCacheKey(query)
+	if suggestions, ok := p.suggestions.Get(key); ok {
+		return suggestions, nil
+	}
+
+	suggestions, err := p.pasteRepository.Suggest(ctx, query, suggestLimit(query))
 	if err != nil {
-		log.Error(err)
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.NewInternalError("Error while quering db..."))
+		return nil, err
 	}
 
-	if existed == nil {
-		return c.Status(fiber.StatusNotFound).JSON(responses.NewBadRequestError("Paste not found"))
+	p.suggestions.Set(key, suggestions)
+
+	return suggestions, nil
+}
+
+func (p *pasteService) Update(ctx context.Context, id int, dto *dtos.UpdatePasteDto, precondition Precondition) (*models.PasteModel, error) {
+	newPaste, err := within(ctx, p.uow, func(r *repositories.Repositories) (*models.PasteModel, error) {
+		return p.updatePaste(ctx, r, id, dto, precondition)
+	})
+
+	if err != nil {
+		return nil, err
 	}
 
-	return c.Status(fiber.StatusOK).JSON(existed)
+	p.suggestions.Purge()
+
+	return newPaste, nil
 }
 
-func (p *pasteService) Search(c *fiber.Ctx) error {
-	url := c.BaseURL() + c.OriginalURL()
-	queryObj, err := querymap.FromURLStringToStruct[dtos.PastesSearchQueryDto](url)
+// Patch applies a merge patch (RFC 7396) or a JSON Patch (RFC 6902) to the
+// paste and writes only the columns that actually changed.
+func (p *pasteService) Patch(ctx context.Context, id int, document []byte, contentType string, precondition Precondition) (*models.PasteModel, error) {
+	newPaste, err := within(ctx, p.uow, func(r *repositories.Repositories) (*models.PasteModel, error) {
+		return p.patchPaste(ctx, r, id, document, contentType, precondition)
+	})
+
 	if err != nil {
-		log.Error(err)
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.NewInternalError("Failed to parse query..."))
+		return nil, err
 	}
-	existed, err := p.pasteRepository.FindMany(queryObj.Filter, queryObj.Pagination)
+
+	p.suggestions.Purge()
+
+	return newPaste, nil
+}
+
+func (p *pasteService) patchPaste(ctx context.Context, r *repositories.Repositories, id int, document []byte, contentType string, check Precondition) (*models.PasteModel, error) {
+	filter := &dtos.PastesFilterDto{PasteId: &id}
+
+	existed, err := r.Pastes.FindOne(ctx, filter, nil)
 
 	if err != nil {
-		log.Error(err)
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.NewInternalError("Error while quering db..."))
+		return nil, err
 	}
 
 	if existed == nil {
-		return c.Status(fiber.StatusNotFound).JSON(responses.NewBadRequestError("Paste not found"))
+		return nil, domain.NewNotFoundError("Paste not found")
 	}
 
-	var limit int = 10
+	version, ok := check(existed.Version)
+
+	if !ok {
+		return nil, domain.NewPreconditionFailedError("Paste was modified by someone else")
+	}
+
+	original := &dtos.UpdatePasteDto{Title: existed.Title, Paste: existed.Paste}
+	patched, err := patch.Apply(original, document, contentType)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if violations := validators.Validate(ctx, patched); violations != nil {
+		return nil, violations
+	}
+
+	var changes dtos.PatchPasteDto
+
+	if patched.Title != original.Title {
+		changes.Title = &patched.Title
+
+		if err := p.ensureUniqueTitle(ctx, r.Pastes, patched.Title, &id); err != nil {
+			return nil, err
+		}
+	}
+
+	if patched.Paste != original.Paste {
+		changes.Paste = &patched.Paste
+	}
+
+	newPaste, err := r.Pastes.Patch(ctx, filter, &changes, version)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if newPaste == nil && version != nil {
+		return nil, domain.NewPreconditionFailedError("Paste was modified by someone else")
+	}
+
+	if newPaste == nil {
+		return nil, domain.NewNotFoundError("Paste not found")
+	}
+
+	// A patch that changes nothing does not bump the version.
+	if newPaste.Version != existed.Version {
+		if err := p.record(ctx, r, enums.AuditUpdate, newPaste); err != nil {
+			return nil, err
+		}
+	}
+
+	return newPaste, nil
+}
+
+func (p *pasteService) createPaste(ctx context.Context, r *repositories.Repositories, body *dtos.PasteDto) (*models.PasteModel, error) {
+	if violations := validators.Validate(ctx, body); violations != nil {
+		return nil, violations
+	}
 
-	if queryObj.Pagination != nil && queryObj.Pagination.Limit != nil {
-		limit = *queryObj.Pagination.Limit
+	if err := p.ensureUniqueTitle(ctx, r.Pastes, body.Title, nil); err != nil {
+		return nil, err
+	}
+
+	newPaste, err := r.Pastes.Create(ctx, body)
+	if err != nil {
+		return nil, err
 	}
 
-	return c.Status(fiber.StatusOK).JSON(responses.NewPaginationResponse(&existed, len(existed) > limit))
+	if err := p.record(ctx, r, enums.AuditCreate, newPaste); err != nil {
+		return nil, err
+	}
+
+	return newPaste, nil
 }
 
-func (p *pasteService) Update(c *fiber.Ctx) error {
-	var body dtos.UpdatePasteDto
+func (p *pasteService) updatePaste(ctx context.Context, r *repositories.Repositories, id int, body *dtos.UpdatePasteDto, check Precondition) (*models.PasteModel, error) {
+	if violations := validators.Validate(ctx, body); violations != nil {
+		return nil, violations
+	}
 
-	queryObj, err := querymap.FromURLStringToStruct[dtos.PastesFilterDto](c.BaseURL() + c.OriginalURL())
-	filterViolations := validators.AppValidatorInstance.Validate(queryObj)
+	filter := &dtos.PastesFilterDto{PasteId: &id}
 
+	current, err := r.Pastes.FindOne(ctx, filter, nil)
 	if err != nil {
-		log.Error(err)
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.NewInternalError("Failed to parse query..."))
+		return nil, err
 	}
 
-	if filterViolations != nil {
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(filterViolations)
+	if current == nil {
+		return nil, domain.NewNotFoundError("Paste not found")
 	}
 
-	if p.isEmptyFilter(queryObj) {
-		return c.Status(fiber.StatusBadRequest).JSON(responses.NewBadRequestError("Empty query parametrs"))
+	version, ok := check(current.Version)
+	if !ok {
+		return nil, domain.NewPreconditionFailedError("Paste was modified by someone else")
 	}
 
-	if err := c.BodyParser(&body); err != nil {
-		log.Error(err)
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.NewInternalError())
+	if err := p.ensureUniqueTitle(ctx, r.Pastes, body.Title, &id); err != nil {
+		return nil, err
 	}
 
-	bodyViolations := validators.AppValidatorInstance.Validate(body)
+	newPaste, err := r.Pastes.Update(ctx, filter, body, version)
+	if err != nil {
+		return nil, err
+	}
 
-	if bodyViolations != nil {
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(bodyViolations)
+	if newPaste == nil && version != nil {
+		return nil, domain.NewPreconditionFailedError("Paste was modified by someone else")
 	}
 
-	strict := true
-	existed, err := p.pasteRepository.FindOne(&dtos.PastesFilterDto{
-		Search: &body.Title,
-		Strict: &strict,
-	}, nil)
+	if newPaste == nil {
+		return nil, domain.NewNotFoundError("Paste not found")
+	}
+
+	if err := p.record(ctx, r, enums.AuditUpdate, newPaste); err != nil {
+		return nil, err
+	}
+
+	return newPaste, nil
+}
 
+func (p *pasteService) deletePaste(ctx context.Context, r *repositories.Repositories, id int, check Precondition) error {
+	filter := &dtos.PastesFilterDto{PasteId: &id}
+
+	existed, err := r.Pastes.FindOne(ctx, filter, nil)
 	if err != nil {
-		log.Errorf("While quering db %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.NewInternalError())
+		return err
 	}
 
-	if existed != nil {
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(responses.NewValidationError("Paste already exists", []responses.Violation{
-			*responses.NewViolation("Paste already exists", "title"),
-		}))
+	if existed == nil {
+		return domain.NewNotFoundError("Paste not found")
 	}
 
-	newPaste, err := p.pasteRepository.Update(queryObj, &body)
+	version, ok := check(existed.Version)
+	if !ok {
+		return domain.NewPreconditionFailedError("Paste was modified by someone else")
+	}
 
+	deleted, err := r.Pastes.Delete(ctx, filter, version)
 	if err != nil {
-		log.Errorf("While quering db %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.NewInternalError())
+		return err
+	}
+
+	if !deleted && version != nil {
+		return domain.NewPreconditionFailedError("Paste was modified by someone else")
+	}
+
+	if !deleted {
+		return domain.NewNotFoundError("Paste not found")
+	}
+
+	return p.record(ctx, r, enums.AuditDelete, existed)
+}
+
+// record writes the audit entry for a change of paste and, unless it was
+// deleted, its new revision.
+func (p *pasteService) record(ctx context.Context, r *repositories.Repositories, action enums.AuditAction, paste *models.PasteModel) error {
+	if err := r.Audit.Record(ctx, enums.AuditPaste, paste.Id, action, paste.Version); err != nil {
+		return err
+	}
+
+	if action == enums.AuditDelete {
+		return nil
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(newPaste)
+	return r.Revisions.Create(ctx, paste)
 }
 
-func (p *pasteService) isEmptyFilter(filter *dtos.PastesFilterDto) bool {
-	return filter.Search == nil && filter.UserId == nil && filter.PasteId == nil
+// ensureUniqueTitle fails with a conflict when another paste than except already
+// has the title.
+func (p *pasteService) ensureUniqueTitle(ctx context.Context, r repositories.PasteRepository, title string, except *int) error {
+	strict := true
+	existed, err := r.FindOne(ctx, &dtos.PastesFilterDto{
+		Search: &title,
+		Strict: &strict,
+	}, nil)
+
+	if err != nil {
+		return err
+	}
+
+	if existed != nil && (except == nil || existed.Id != *except) {
+		return domain.NewConflictError("Paste already exists", "title")
+	}
+
+	return nil
 }
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"api/internal/domain"
+	"api/internal/dtos"
+	"api/internal/enums"
+	"api/internal/metrics"
+	"api/internal/models"
+	"api/internal/repositories"
+	"api/internal/services/transfer"
+	"api/internal/services/validators"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	importRenameLimit = 100
+	pasteTitleMaxLen  = 32
+)
+
+type ImportReport struct {
+	Created     int
+	Overwritten int
+	Renamed     int
+	Skipped     int
+	Failures    []*ImportFailure
+}
+
+// ImportFailure is a record that was not imported and the line it starts on.
+type ImportFailure struct {
+	Line int
+	Err  error
+}
+
+// Export calls fn for every paste matching filter, in id order and without
+// loading them all at once. Authors are given as socialId so the records can
+// be imported on another server. The export reads a single snapshot and is
+// not bound by statement_timeout, so a slow reader does not cut it short.
+func (p *pasteService) Export(ctx context.Context, filter *dtos.PastesFilterDto, fn func(record *models.PasteRecordModel) error) error {
+	if filter != nil {
+		if violations := validators.Validate(ctx, filter); violations != nil {
+			return violations
+		}
+	}
+
+	return p.uow.Stream(ctx, func(r *repositories.Repositories) error {
+		return r.Pastes.Export(ctx, filter, fn)
+	})
+}
+
+// Import creates pastes from the records of reader. Every record is validated
+// on its own and failures are reported with their line; strategy decides what
+// happens to records whose title is already taken.
+func (p *pasteService) Import(ctx context.Context, reader transfer.Reader, strategy enums.ConflictStrategy) (*ImportReport, error) {
+	report := &ImportReport{Failures: []*ImportFailure{}}
+	authors := map[string]int{}
+
+	for {
+		line, record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+
+		var recordErr *transfer.RecordError
+		if errors.As(err, &recordErr) {
+			report.Failures = append(report.Failures, &ImportFailure{Line: line, Err: domain.NewInvalidInputError(recordErr.Err.Error())})
+			continue
+		}
+
+		if err != nil {
+			return nil, domain.NewInvalidInputError(err.Error())
+		}
+
+		outcome, err := p.importRecord(ctx, record, strategy, authors)
+		if err != nil {
+			if !domain.IsClientError(err) {
+				return nil, err
+			}
+			report.Failures = append(report.Failures, &ImportFailure{Line: line, Err: err})
+			continue
+		}
+
+		switch outcome {
+		case importCreated:
+			report.Created++
+		case importOverwritten:
+			report.Overwritten++
+		case importRenamed:
+			report.Renamed++
+		case importSkipped:
+			report.Skipped++
+		}
+	}
+
+	if report.Created+report.Overwritten+report.Renamed > 0 {
+		metrics.PastesCreated.Add(float64(report.Created + report.Renamed))
+		p.suggestions.Purge()
+	}
+
+	p.logger.InfoContext(ctx, "Pastes imported",
+		"created", report.Created,
+		"overwritten", report.Overwritten,
+		"renamed", report.Renamed,
+		"skipped", report.Skipped,
+		"failed", len(report.Failures),
+	)
+
+	return report, nil
+}
+
+// importOutcome is what happened to a successfully imported record.
+type importOutcome int
+
+const (
+	importCreated importOutcome = iota
+	importOverwritten
+	importRenamed
+	importSkipped
+)
+
+// importRecord writes a single record in a unit of work of its own, so a
+// failing record does not undo the ones before it.
+func (p *pasteService) importRecord(ctx context.Context, record *dtos.ImportPasteDto, strategy enums.ConflictStrategy, authors map[string]int) (importOutcome, error) {
+	if violations := validators.Validate(ctx, record); violations != nil {
+		return 0, violations
+	}
+
+	userId, ok := authors[record.SocialId]
+	if !ok {
+		author, err := p.userRepository.Find(ctx, &dtos.UserFiltersDto{SocialId: &record.SocialId})
+		if err != nil {
+			return 0, err
+		}
+
+		if author == nil {
+			return 0, domain.NewValidationError("Author not found", domain.NewViolation("Author not found", "socialId"))
+		}
+
+		userId = author.Id
+		authors[record.SocialId] = userId
+	}
+
+	return within(ctx, p.uow, func(r *repositories.Repositories) (importOutcome, error) {
+		title := record.Title
+
+		existed, err := p.findByTitle(ctx, r.Pastes, title)
+		if err != nil {
+			return 0, err
+		}
+
+		if existed != nil {
+			switch strategy {
+			case enums.ConflictSkip:
+				return importSkipped, nil
+			case enums.ConflictOverwrite:
+				if _, err := p.updatePaste(ctx, r, existed.Id, &dtos.UpdatePasteDto{Title: title, Paste: record.Paste}, VersionPrecondition(nil)); err != nil {
+					return 0, err
+				}
+				return importOverwritten, nil
+			case enums.ConflictRename:
+				if title, err = p.freeTitle(ctx, r.Pastes, title); err != nil {
+					return 0, err
+				}
+			}
+		}
+
+		if _, err := p.createPaste(ctx, r, &dtos.PasteDto{Title: title, Paste: record.Paste, UserId: userId}); err != nil {
+			return 0, err
+		}
+
+		if title != record.Title {
+			return importRenamed, nil
+		}
+		return importCreated, nil
+	})
+}
+
+func (p *pasteService) findByTitle(ctx context.Context, r repositories.PasteRepository, title string) (*models.PasteModel, error) {
+	strict := true
+	return r.FindOne(ctx, &dtos.PastesFilterDto{Search: &title, Strict: &strict}, nil)
+}
+
+// freeTitle finds the first of "title (2)", "title (3)", ... that is not taken,
+// shortening title so the suffix still fits into the column.
+func (p *pasteService) freeTitle(ctx context.Context, r repositories.PasteRepository, title string) (string, error) {
+	for n := 2; n < importRenameLimit; n++ {
+		suffix := fmt.Sprintf(" (%d)", n)
+		base := []rune(title)
+		if len(base)+len(suffix) > pasteTitleMaxLen {
+			base = base[:pasteTitleMaxLen-len(suffix)]
+		}
+
+		candidate := string(base) + suffix
+		existed, err := p.findByTitle(ctx, r, candidate)
+		if err != nil {
+			return "", err
+		}
+
+		if existed == nil {
+			return candidate, nil
+		}
+	}
+
+	return "", domain.NewConflictError("Paste already exists", "title")
+}
@@ -0,0 +1,60 @@
+package patch
+
+import (
+	"api/internal/domain"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+const (
+	ContentTypeMergePatch = "application/merge-patch+json"
+	ContentTypeJsonPatch  = "application/json-patch+json"
+	ContentTypeJson       = "application/json"
+)
+
+// Apply applies the request body to original and decodes the result into a new T.
+// RFC 7396 merge patches are expected for application/merge-patch+json and plain
+// application/json, RFC 6902 operations for application/json-patch+json.
+// Fields unknown to T are rejected, so a patch cannot touch read-only columns.
+func Apply[T any](original *T, body []byte, contentType string) (*T, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, domain.ErrUnsupportedMediaType
+	}
+
+	document, err := json.Marshal(original)
+	if err != nil {
+		return nil, err
+	}
+
+	var patched []byte
+	switch mediaType {
+	case ContentTypeMergePatch, ContentTypeJson:
+		patched, err = jsonpatch.MergePatch(document, body)
+	case ContentTypeJsonPatch:
+		var operations jsonpatch.Patch
+		operations, err = jsonpatch.DecodePatch(body)
+		if err == nil {
+			patched, err = operations.Apply(document)
+		}
+	default:
+		return nil, domain.ErrUnsupportedMediaType
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidPatch, err)
+	}
+
+	var result T
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&result); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidPatch, err)
+	}
+
+	return &result, nil
+}
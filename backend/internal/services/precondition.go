@@ -0,0 +1,16 @@
+package services
+
+// Precondition reports the version a write must be conditioned on, nil for an
+// unconditional write, and false when the current version of the entity does
+// not satisfy the caller.
+type Precondition func(current int) (*int, bool)
+
+// VersionPrecondition requires the entity to still be at expected, if given.
+func VersionPrecondition(expected *int) Precondition {
+	return func(current int) (*int, bool) {
+		if expected == nil {
+			return nil, true
+		}
+		return expected, *expected == current
+	}
+}
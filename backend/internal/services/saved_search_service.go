@@ -0,0 +1,208 @@
+package services
+
+import (
+	"api/internal/domain"
+	"api/internal/dtos"
+	"api/internal/models"
+	"api/internal/repositories"
+	"api/internal/responses"
+	"api/internal/services/validators"
+	"context"
+	"log/slog"
+)
+
+type SavedSearchService interface {
+	Find(ctx context.Context, filter *dtos.SavedSearchFilterDto) ([]*models.SavedSearchModel, error)
+	Create(ctx context.Context, dto *dtos.SavedSearchDto) (*models.SavedSearchModel, error)
+	Update(ctx context.Context, id int, dto *dtos.UpdateSavedSearchDto) (*models.SavedSearchModel, error)
+	Delete(ctx context.Context, id int) error
+	Execute(ctx context.Context, target *dtos.SavedSearchFilterDto, pagination *dtos.PaginationDto, shape *dtos.ResponseShapeDto) (*responses.PaginationResponse[any], error)
+}
+
+type savedSearchService struct {
+	savedSearchRepository repositories.SavedSearchRepository
+	pasteService          PasteService
+	logger                *slog.Logger
+}
+
+func NewSavedSearchService(r repositories.SavedSearchRepository, pasteService PasteService, logger *slog.Logger) SavedSearchService {
+	return &tracingSavedSearchService{next: &savedSearchService{savedSearchRepository: r, pasteService: pasteService, logger: logger}}
+}
+
+func (s *savedSearchService) Find(ctx context.Context, filter *dtos.SavedSearchFilterDto) ([]*models.SavedSearchModel, error) {
+	if s.isEmptyOwner(filter) {
+		return nil, domain.NewInvalidInputError("userId or socialId is required")
+	}
+
+	if violations := validators.Validate(ctx, filter); violations != nil {
+		return nil, violations
+	}
+
+	return s.savedSearchRepository.FindMany(ctx, filter)
+}
+
+func (s *savedSearchService) Create(ctx context.Context, dto *dtos.SavedSearchDto) (*models.SavedSearchModel, error) {
+	if violations := validators.Validate(ctx, dto); violations != nil {
+		return nil, violations
+	}
+
+	existed, err := s.savedSearchRepository.FindOne(ctx, &dtos.SavedSearchFilterDto{
+		UserId: &dto.UserId,
+		Name:   &dto.Name,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if existed != nil {
+		return nil, domain.NewConflictError("Saved search already exists", "name")
+	}
+
+	created, err := s.savedSearchRepository.Create(ctx, dto)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "Saved search created", "userId", created.UserId, "name", created.Name)
+
+	return created, nil
+}
+
+func (s *savedSearchService) Update(ctx context.Context, id int, dto *dtos.UpdateSavedSearchDto) (*models.SavedSearchModel, error) {
+	if violations := validators.Validate(ctx, dto); violations != nil {
+		return nil, violations
+	}
+
+	existed, err := s.savedSearchRepository.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if existed == nil {
+		return nil, domain.NewNotFoundError("Saved search not found")
+	}
+
+	if dto.Name != existed.Name {
+		duplicate, err := s.savedSearchRepository.FindOne(ctx, &dtos.SavedSearchFilterDto{
+			UserId: &existed.UserId,
+			Name:   &dto.Name,
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		if duplicate != nil {
+			return nil, domain.NewConflictError("Saved search already exists", "name")
+		}
+	}
+
+	updated, err := s.savedSearchRepository.Update(ctx, id, dto)
+	if err != nil {
+		return nil, err
+	}
+
+	if updated == nil {
+		return nil, domain.NewNotFoundError("Saved search not found")
+	}
+
+	return updated, nil
+}
+
+func (s *savedSearchService) Delete(ctx context.Context, id int) error {
+	deleted, err := s.savedSearchRepository.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !deleted {
+		return domain.NewNotFoundError("Saved search not found")
+	}
+
+	s.logger.InfoContext(ctx, "Saved search deleted", "id", id)
+
+	return nil
+}
+
+// Execute runs the stored query of a saved search. Fields of pagination, if
+// given, override the stored ones one by one.
+func (s *savedSearchService) Execute(ctx context.Context, target *dtos.SavedSearchFilterDto, pagination *dtos.PaginationDto, shape *dtos.ResponseShapeDto) (*responses.PaginationResponse[any], error) {
+	if err := s.validateTarget(ctx, target); err != nil {
+		return nil, err
+	}
+
+	if pagination != nil {
+		if violations := validators.Validate(ctx, pagination); violations != nil {
+			return nil, violations
+		}
+	}
+
+	existed, err := s.findTarget(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	searchQuery := existed.Query
+	searchQuery.Pagination = s.mergePagination(searchQuery.Pagination, pagination)
+
+	return s.pasteService.Search(ctx, &searchQuery, shape)
+}
+
+func (s *savedSearchService) validateTarget(ctx context.Context, target *dtos.SavedSearchFilterDto) error {
+	if violations := validators.Validate(ctx, target); violations != nil {
+		return violations
+	}
+
+	if s.isEmptyTarget(target) {
+		return domain.NewInvalidInputError("name and userId or socialId are required")
+	}
+
+	return nil
+}
+
+func (s *savedSearchService) findTarget(ctx context.Context, target *dtos.SavedSearchFilterDto) (*models.SavedSearchModel, error) {
+	existed, err := s.savedSearchRepository.FindOne(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	if existed == nil {
+		return nil, domain.NewNotFoundError("Saved search not found")
+	}
+
+	return existed, nil
+}
+
+func (s *savedSearchService) mergePagination(stored *dtos.PaginationDto, override *dtos.PaginationDto) *dtos.PaginationDto {
+	if override == nil {
+		return stored
+	}
+
+	if stored == nil {
+		return override
+	}
+
+	merged := *stored
+	if override.StartFrom != nil {
+		merged.StartFrom = override.StartFrom
+	}
+	if override.Order != nil {
+		merged.Order = override.Order
+	}
+	if override.Limit != nil {
+		merged.Limit = override.Limit
+	}
+	if override.Sort != nil {
+		merged.Sort = override.Sort
+	}
+	return &merged
+}
+
+func (s *savedSearchService) isEmptyOwner(q *dtos.SavedSearchFilterDto) bool {
+	return q.UserId == nil && q.SocialId == nil
+}
+
+func (s *savedSearchService) isEmptyTarget(q *dtos.SavedSearchFilterDto) bool {
+	return s.isEmptyOwner(q) || q.Name == nil
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"api/internal/dtos"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Suggest of the paste and user services answers autocomplete requests with
+// at most suggestMaxLimit `{id, label}` pairs. Hot prefixes are served from an
+// in-process cache of suggestCacheSize entries living for suggestCacheTTL.
+const (
+	suggestMaxLimit  = 25
+	suggestCacheSize = 1024
+	suggestCacheTTL  = 30 * time.Second
+)
+
+func suggestLimit(q *dtos.SuggestQueryDto) int {
+	if q.Limit != nil && *q.Limit < suggestMaxLimit {
+		return *q.Limit
+	}
+	return suggestMaxLimit
+}
+
+// suggestCacheKey identifies a suggest request by its normalized prefix,
+// limit and owner scope.
+func suggestCacheKey(q *dtos.SuggestQueryDto) string {
+	text := ""
+	if q.Query != nil {
+		text = strings.ToLower(strings.TrimSpace(*q.Query))
+	}
+
+	scope := ""
+	if q.UserId != nil {
+		scope = fmt.Sprintf("user:%d", *q.UserId)
+	} else if q.SocialId != nil {
+		scope = "social:" + *q.SocialId
+	}
+
+	return fmt.Sprintf("%s|%d|%s", scope, suggestLimit(q), text)
+}
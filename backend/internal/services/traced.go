@@ -0,0 +1,180 @@
+package services
+
+import (
+	"api/internal/dtos"
+	"api/internal/enums"
+	"api/internal/models"
+	"api/internal/responses"
+	"api/internal/services/transfer"
+	"api/internal/tracing"
+	"context"
+)
+
+// traced runs fn in a span named name.
+func traced[T any](ctx context.Context, name string, fn func(ctx context.Context) (T, error)) (T, error) {
+	ctx, span := tracing.Start(ctx, name)
+	result, err := fn(ctx)
+	tracing.End(span, err)
+	return result, err
+}
+
+// tracedErr is traced for methods returning nothing but an error.
+func tracedErr(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	ctx, span := tracing.Start(ctx, name)
+	err := fn(ctx)
+	tracing.End(span, err)
+	return err
+}
+
+// tracingPasteService makes a span of every call to next.
+type tracingPasteService struct {
+	next PasteService
+}
+
+func (t *tracingPasteService) Find(ctx context.Context, filter *dtos.PastesFilterDto, shape *dtos.ResponseShapeDto) (*PasteView, error) {
+	return traced(ctx, "PasteService.Find", func(ctx context.Context) (*PasteView, error) {
+		return t.next.Find(ctx, filter, shape)
+	})
+}
+
+func (t *tracingPasteService) FindOne(ctx context.Context, id int, shape *dtos.ResponseShapeDto) (*PasteView, error) {
+	return traced(ctx, "PasteService.FindOne", func(ctx context.Context) (*PasteView, error) {
+		return t.next.FindOne(ctx, id, shape)
+	})
+}
+
+func (t *tracingPasteService) Search(ctx context.Context, query *dtos.PastesSearchQueryDto, shape *dtos.ResponseShapeDto) (*responses.PaginationResponse[any], error) {
+	return traced(ctx, "PasteService.Search", func(ctx context.Context) (*responses.PaginationResponse[any], error) {
+		return t.next.Search(ctx, query, shape)
+	})
+}
+
+func (t *tracingPasteService) Suggest(ctx context.Context, query *dtos.SuggestQueryDto) ([]*models.SuggestionModel, error) {
+	return traced(ctx, "PasteService.Suggest", func(ctx context.Context) ([]*models.SuggestionModel, error) {
+		return t.next.Suggest(ctx, query)
+	})
+}
+
+func (t *tracingPasteService) Create(ctx context.Context, dto *dtos.PasteDto) (*models.PasteModel, error) {
+	return traced(ctx, "PasteService.Create", func(ctx context.Context) (*models.PasteModel, error) {
+		return t.next.Create(ctx, dto)
+	})
+}
+
+func (t *tracingPasteService) Update(ctx context.Context, id int, dto *dtos.UpdatePasteDto, precondition Precondition) (*models.PasteModel, error) {
+	return traced(ctx, "PasteService.Update", func(ctx context.Context) (*models.PasteModel, error) {
+		return t.next.Update(ctx, id, dto, precondition)
+	})
+}
+
+func (t *tracingPasteService) Patch(ctx context.Context, id int, document []byte, contentType string, precondition Precondition) (*models.PasteModel, error) {
+	return traced(ctx, "PasteService.Patch", func(ctx context.Context) (*models.PasteModel, error) {
+		return t.next.Patch(ctx, id, document, contentType, precondition)
+	})
+}
+
+func (t *tracingPasteService) Delete(ctx context.Context, id int, precondition Precondition) error {
+	return tracedErr(ctx, "PasteService.Delete", func(ctx context.Context) error {
+		return t.next.Delete(ctx, id, precondition)
+	})
+}
+
+func (t *tracingPasteService) Batch(ctx context.Context, batch *dtos.BatchPastesDto) (*BatchReport, error) {
+	return traced(ctx, "PasteService.Batch", func(ctx context.Context) (*BatchReport, error) {
+		return t.next.Batch(ctx, batch)
+	})
+}
+
+func (t *tracingPasteService) Export(ctx context.Context, filter *dtos.PastesFilterDto, fn func(record *models.PasteRecordModel) error) error {
+	return tracedErr(ctx, "PasteService.Export", func(ctx context.Context) error {
+		return t.next.Export(ctx, filter, fn)
+	})
+}
+
+func (t *tracingPasteService) Import(ctx context.Context, reader transfer.Reader, strategy enums.ConflictStrategy) (*ImportReport, error) {
+	return traced(ctx, "PasteService.Import", func(ctx context.Context) (*ImportReport, error) {
+		return t.next.Import(ctx, reader, strategy)
+	})
+}
+
+// tracingUserService makes a span of every call to next.
+type tracingUserService struct {
+	next UserService
+}
+
+func (t *tracingUserService) Find(ctx context.Context, filter *dtos.UserFiltersDto) (*models.UserModel, error) {
+	return traced(ctx, "UserService.Find", func(ctx context.Context) (*models.UserModel, error) {
+		return t.next.Find(ctx, filter)
+	})
+}
+
+func (t *tracingUserService) FindOne(ctx context.Context, target *dtos.UserFiltersDto) (*models.UserModel, error) {
+	return traced(ctx, "UserService.FindOne", func(ctx context.Context) (*models.UserModel, error) {
+		return t.next.FindOne(ctx, target)
+	})
+}
+
+func (t *tracingUserService) Create(ctx context.Context, dto *dtos.UserDto) (*models.UserModel, error) {
+	return traced(ctx, "UserService.Create", func(ctx context.Context) (*models.UserModel, error) {
+		return t.next.Create(ctx, dto)
+	})
+}
+
+func (t *tracingUserService) Update(ctx context.Context, target *dtos.UserFiltersDto, dto *dtos.UpdateUserDto, precondition Precondition) (*models.UserModel, error) {
+	return traced(ctx, "UserService.Update", func(ctx context.Context) (*models.UserModel, error) {
+		return t.next.Update(ctx, target, dto, precondition)
+	})
+}
+
+func (t *tracingUserService) Patch(ctx context.Context, target *dtos.UserFiltersDto, document []byte, contentType string, precondition Precondition) (*models.UserModel, error) {
+	return traced(ctx, "UserService.Patch", func(ctx context.Context) (*models.UserModel, error) {
+		return t.next.Patch(ctx, target, document, contentType, precondition)
+	})
+}
+
+func (t *tracingUserService) Delete(ctx context.Context, target *dtos.UserFiltersDto, precondition Precondition) error {
+	return tracedErr(ctx, "UserService.Delete", func(ctx context.Context) error {
+		return t.next.Delete(ctx, target, precondition)
+	})
+}
+
+func (t *tracingUserService) Suggest(ctx context.Context, query *dtos.SuggestQueryDto) ([]*models.SuggestionModel, error) {
+	return traced(ctx, "UserService.Suggest", func(ctx context.Context) ([]*models.SuggestionModel, error) {
+		return t.next.Suggest(ctx, query)
+	})
+}
+
+// tracingSavedSearchService makes a span of every call to next.
+type tracingSavedSearchService struct {
+	next SavedSearchService
+}
+
+func (t *tracingSavedSearchService) Find(ctx context.Context, filter *dtos.SavedSearchFilterDto) ([]*models.SavedSearchModel, error) {
+	return traced(ctx, "SavedSearchService.Find", func(ctx context.Context) ([]*models.SavedSearchModel, error) {
+		return t.next.Find(ctx, filter)
+	})
+}
+
+func (t *tracingSavedSearchService) Create(ctx context.Context, dto *dtos.SavedSearchDto) (*models.SavedSearchModel, error) {
+	return traced(ctx, "SavedSearchService.Create", func(ctx context.Context) (*models.SavedSearchModel, error) {
+		return t.next.Create(ctx, dto)
+	})
+}
+
+func (t *tracingSavedSearchService) Update(ctx context.Context, id int, dto *dtos.UpdateSavedSearchDto) (*models.SavedSearchModel, error) {
+	return traced(ctx, "SavedSearchService.Update", func(ctx context.Context) (*models.SavedSearchModel, error) {
+		return t.next.Update(ctx, id, dto)
+	})
+}
+
+func (t *tracingSavedSearchService) Delete(ctx context.Context, id int) error {
+	return tracedErr(ctx, "SavedSearchService.Delete", func(ctx context.Context) error {
+		return t.next.Delete(ctx, id)
+	})
+}
+
+func (t *tracingSavedSearchService) Execute(ctx context.Context, target *dtos.SavedSearchFilterDto, pagination *dtos.PaginationDto, shape *dtos.ResponseShapeDto) (*responses.PaginationResponse[any], error) {
+	return traced(ctx, "SavedSearchService.Execute", func(ctx context.Context) (*responses.PaginationResponse[any], error) {
+		return t.next.Execute(ctx, target, pagination, shape)
+	})
+}
@@ -0,0 +1,182 @@
+package transfer
+
+import (
+	"api/internal/dtos"
+	"api/internal/enums"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var ErrInvalidDocument = errors.New("invalid import document")
+
+// RecordError is a broken record; the reader can go on with the next one.
+type RecordError struct {
+	Line int
+	Err  error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Reader decodes imported paste records one by one.
+//
+// Next returns the record and the line it starts on, io.EOF after the last
+// record, a *RecordError for a record that cannot be decoded and an error
+// wrapping ErrInvalidDocument when the rest of the input cannot be read.
+type Reader interface {
+	Next() (int, *dtos.ImportPasteDto, error)
+}
+
+// FormatOf picks the import format from the request Content-Type.
+func FormatOf(contentType string) enums.TransferFormat {
+	switch {
+	case strings.Contains(contentType, "csv"):
+		return enums.TransferCsv
+	case strings.Contains(contentType, "ndjson"), strings.Contains(contentType, "jsonl"):
+		return enums.TransferNdjson
+	default:
+		return enums.TransferJson
+	}
+}
+
+func NewReader(body []byte, format enums.TransferFormat) Reader {
+	switch format {
+	case enums.TransferCsv:
+		reader := csv.NewReader(bytes.NewReader(body))
+		reader.FieldsPerRecord = -1
+		return &csvReader{reader: reader}
+	case enums.TransferNdjson:
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		scanner.Buffer(make([]byte, 0, 64*1024), len(body)+1)
+		return &ndjsonReader{scanner: scanner}
+	default:
+		return &jsonReader{body: body, decoder: json.NewDecoder(bytes.NewReader(body))}
+	}
+}
+
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *ndjsonReader) Next() (int, *dtos.ImportPasteDto, error) {
+	for r.scanner.Scan() {
+		r.line++
+		text := bytes.TrimSpace(r.scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		var record dtos.ImportPasteDto
+		if err := json.Unmarshal(text, &record); err != nil {
+			return r.line, nil, &RecordError{Line: r.line, Err: err}
+		}
+		return r.line, &record, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return r.line, nil, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+	return r.line, nil, io.EOF
+}
+
+type jsonReader struct {
+	body    []byte
+	decoder *json.Decoder
+	started bool
+}
+
+func (r *jsonReader) Next() (int, *dtos.ImportPasteDto, error) {
+	if !r.started {
+		r.started = true
+		token, err := r.decoder.Token()
+		if err != nil || token != json.Delim('[') {
+			return 1, nil, fmt.Errorf("%w: expected a JSON array", ErrInvalidDocument)
+		}
+	}
+
+	if !r.decoder.More() {
+		if _, err := r.decoder.Token(); err != nil {
+			return r.lineAt(r.decoder.InputOffset()), nil, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+		}
+		return r.lineAt(r.decoder.InputOffset()), nil, io.EOF
+	}
+
+	var raw json.RawMessage
+	if err := r.decoder.Decode(&raw); err != nil {
+		return r.lineAt(r.decoder.InputOffset()), nil, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+
+	end := int(r.decoder.InputOffset())
+	line := r.lineAt(int64(end - len(raw)))
+
+	var record dtos.ImportPasteDto
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return line, nil, &RecordError{Line: line, Err: err}
+	}
+	return line, &record, nil
+}
+
+func (r *jsonReader) lineAt(offset int64) int {
+	return bytes.Count(r.body[:offset], []byte("\n")) + 1
+}
+
+type csvReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func (r *csvReader) Next() (int, *dtos.ImportPasteDto, error) {
+	if r.columns == nil {
+		header, err := r.reader.Read()
+		if err != nil {
+			return 1, nil, fmt.Errorf("%w: missing CSV header", ErrInvalidDocument)
+		}
+
+		r.columns = map[string]int{}
+		for i, name := range header {
+			r.columns[strings.TrimSpace(name)] = i
+		}
+
+		for _, required := range []string{"title", "paste", "socialId"} {
+			if _, ok := r.columns[required]; !ok {
+				return 1, nil, fmt.Errorf("%w: missing CSV column %q", ErrInvalidDocument, required)
+			}
+		}
+	}
+
+	fields, err := r.reader.Read()
+	if err == io.EOF {
+		return 0, nil, io.EOF
+	}
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return parseErr.StartLine, nil, &RecordError{Line: parseErr.StartLine, Err: parseErr.Err}
+	}
+
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+
+	line, _ := r.reader.FieldPos(0)
+	column := func(name string) string {
+		if i := r.columns[name]; i < len(fields) {
+			return fields[i]
+		}
+		return ""
+	}
+
+	return line, &dtos.ImportPasteDto{
+		Title:    column("title"),
+		Paste:    column("paste"),
+		SocialId: column("socialId"),
+	}, nil
+}
@@ -0,0 +1,227 @@
+package transfer
+
+import (
+	"api/internal/dtos"
+	"api/internal/enums"
+	"api/internal/models"
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// readAll collects the records of r, with the lines of the broken ones.
+func readAll(t *testing.T, r Reader) ([]dtos.ImportPasteDto, []int, error) {
+	t.Helper()
+
+	var (
+		records []dtos.ImportPasteDto
+		broken  []int
+	)
+
+	for {
+		_, record, err := r.Next()
+		if err == io.EOF {
+			return records, broken, nil
+		}
+
+		var recordErr *RecordError
+		if errors.As(err, &recordErr) {
+			broken = append(broken, recordErr.Line)
+			continue
+		}
+		if err != nil {
+			return records, broken, err
+		}
+
+		records = append(records, *record)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	created := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	records := []*models.PasteRecordModel{
+		{Id: 1, Title: "first", Paste: "plain", SocialId: "100", CreatedAt: created, UpdatedAt: created},
+		{Id: 2, Title: "quoted, \"comma\"", Paste: "two\nlines", SocialId: "200", CreatedAt: created, UpdatedAt: created},
+	}
+	want := []dtos.ImportPasteDto{
+		{Title: "first", Paste: "plain", SocialId: "100"},
+		{Title: "quoted, \"comma\"", Paste: "two\nlines", SocialId: "200"},
+	}
+
+	for _, format := range []enums.TransferFormat{enums.TransferJson, enums.TransferNdjson, enums.TransferCsv} {
+		t.Run(string(format), func(t *testing.T) {
+			var buffer bytes.Buffer
+			writer := NewWriter(&buffer, format)
+			for _, record := range records {
+				if err := writer.Write(record); err != nil {
+					t.Fatalf("Write: %v", err)
+				}
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			got, broken, err := readAll(t, NewReader(buffer.Bytes(), format))
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if len(broken) > 0 {
+				t.Fatalf("broken records on lines %v", broken)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("records = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestEmptyExport(t *testing.T) {
+	tests := []struct {
+		format enums.TransferFormat
+		want   string
+	}{
+		{format: enums.TransferJson, want: "[]"},
+		{format: enums.TransferNdjson, want: ""},
+		{format: enums.TransferCsv, want: "id,title,paste,socialId,createdAt,updatedAt\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var buffer bytes.Buffer
+			if err := NewWriter(&buffer, tt.format).Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+			if buffer.String() != tt.want {
+				t.Errorf("output = %q, want %q", buffer.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestFlush(t *testing.T) {
+	record := &models.PasteRecordModel{Id: 1, Title: "a", Paste: "b", SocialId: "1"}
+
+	for _, format := range []enums.TransferFormat{enums.TransferJson, enums.TransferNdjson, enums.TransferCsv} {
+		t.Run(string(format), func(t *testing.T) {
+			var buffer bytes.Buffer
+			writer := NewWriter(&buffer, format)
+			if err := writer.Write(record); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			if err := writer.Flush(); err != nil {
+				t.Fatalf("Flush: %v", err)
+			}
+			if !bytes.Contains(buffer.Bytes(), []byte(`"a"`)) && !bytes.Contains(buffer.Bytes(), []byte(",a,")) {
+				t.Errorf("record not written after Flush, got %q", buffer.String())
+			}
+		})
+	}
+}
+
+func TestReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  enums.TransferFormat
+		body    string
+		want    []dtos.ImportPasteDto
+		broken  []int
+		invalid bool
+	}{
+		{
+			name:   "csv columns in any order",
+			format: enums.TransferCsv,
+			body:   "socialId,paste,title\n1,text,name\n",
+			want:   []dtos.ImportPasteDto{{Title: "name", Paste: "text", SocialId: "1"}},
+		},
+		{
+			name:   "csv short row leaves the rest empty",
+			format: enums.TransferCsv,
+			body:   "title,paste,socialId\nname,text\n",
+			want:   []dtos.ImportPasteDto{{Title: "name", Paste: "text"}},
+		},
+		{
+			name:    "csv header without a column",
+			format:  enums.TransferCsv,
+			body:    "title,paste\nname,text\n",
+			invalid: true,
+		},
+		{
+			name:    "csv without a header",
+			format:  enums.TransferCsv,
+			body:    "",
+			invalid: true,
+		},
+		{
+			name:   "csv broken quote",
+			format: enums.TransferCsv,
+			body:   "title,paste,socialId\nname,\"te\"xt,1\nok,text,2\n",
+			want:   []dtos.ImportPasteDto{{Title: "ok", Paste: "text", SocialId: "2"}},
+			broken: []int{2},
+		},
+		{
+			name:   "ndjson skips blank lines and goes on after a broken one",
+			format: enums.TransferNdjson,
+			body:   "{\"title\":\"a\",\"paste\":\"b\",\"socialId\":\"1\"}\n\n{broken\n{\"title\":\"c\",\"paste\":\"d\",\"socialId\":\"2\"}\n",
+			want: []dtos.ImportPasteDto{
+				{Title: "a", Paste: "b", SocialId: "1"},
+				{Title: "c", Paste: "d", SocialId: "2"},
+			},
+			broken: []int{3},
+		},
+		{
+			name:   "json reports the line of a broken record",
+			format: enums.TransferJson,
+			body:   "[\n{\"title\":\"a\",\"paste\":\"b\",\"socialId\":\"1\"},\n{\"title\":1}\n]",
+			want:   []dtos.ImportPasteDto{{Title: "a", Paste: "b", SocialId: "1"}},
+			broken: []int{3},
+		},
+		{
+			name:    "json that is not an array",
+			format:  enums.TransferJson,
+			body:    `{"title":"a"}`,
+			invalid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, broken, err := readAll(t, NewReader([]byte(tt.body), tt.format))
+
+			if tt.invalid {
+				if !errors.Is(err, ErrInvalidDocument) {
+					t.Fatalf("err = %v, want ErrInvalidDocument", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("records = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(broken, tt.broken) {
+				t.Errorf("broken lines = %v, want %v", broken, tt.broken)
+			}
+		})
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	tests := map[string]enums.TransferFormat{
+		"text/csv; charset=utf-8": enums.TransferCsv,
+		"application/x-ndjson":    enums.TransferNdjson,
+		"application/jsonl":       enums.TransferNdjson,
+		"application/json":        enums.TransferJson,
+		"":                        enums.TransferJson,
+	}
+
+	for contentType, want := range tests {
+		if got := FormatOf(contentType); got != want {
+			t.Errorf("FormatOf(%q) = %s, want %s", contentType, got, want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package transfer
+
+import (
+	"api/internal/enums"
+	"api/internal/models"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+var csvHeader = []string{"id", "title", "paste", "socialId", "createdAt", "updatedAt"}
+
+// ContentType returns the media type an export in format is served with.
+func ContentType(format enums.TransferFormat) string {
+	switch format {
+	case enums.TransferCsv:
+		return "text/csv; charset=utf-8"
+	case enums.TransferNdjson:
+		return "application/x-ndjson"
+	default:
+		return "application/json"
+	}
+}
+
+// Writer encodes paste records one by one, so an export never has to hold the
+// whole collection in memory.
+type Writer struct {
+	w      io.Writer
+	format enums.TransferFormat
+	csv    *csv.Writer
+	count  int
+}
+
+func NewWriter(w io.Writer, format enums.TransferFormat) *Writer {
+	writer := &Writer{w: w, format: format}
+	if format == enums.TransferCsv {
+		writer.csv = csv.NewWriter(w)
+	}
+	return writer
+}
+
+func (w *Writer) Write(record *models.PasteRecordModel) error {
+	defer func() { w.count++ }()
+
+	switch w.format {
+	case enums.TransferCsv:
+		if w.count == 0 {
+			if err := w.csv.Write(csvHeader); err != nil {
+				return err
+			}
+		}
+		return w.csv.Write([]string{
+			strconv.Itoa(record.Id),
+			record.Title,
+			record.Paste,
+			record.SocialId,
+			record.CreatedAt.Format(time.RFC3339),
+			record.UpdatedAt.Format(time.RFC3339),
+		})
+	case enums.TransferNdjson:
+		return w.writeJson(record, "", "\n")
+	default:
+		prefix := ","
+		if w.count == 0 {
+			prefix = "["
+		}
+		return w.writeJson(record, prefix, "")
+	}
+}
+
+// Flush hands the records written so far to the underlying writer. The CSV
+// encoder keeps its own buffer, the other formats write through.
+func (w *Writer) Flush() error {
+	if w.csv == nil {
+		return nil
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// Close finishes the document; it must be called even if nothing was written.
+func (w *Writer) Close() error {
+	switch w.format {
+	case enums.TransferCsv:
+		if w.count == 0 {
+			if err := w.csv.Write(csvHeader); err != nil {
+				return err
+			}
+		}
+		w.csv.Flush()
+		return w.csv.Error()
+	case enums.TransferNdjson:
+		return nil
+	default:
+		closing := "]"
+		if w.count == 0 {
+			closing = "[]"
+		}
+		_, err := io.WriteString(w.w, closing)
+		return err
+	}
+}
+
+func (w *Writer) writeJson(record *models.PasteRecordModel, prefix string, suffix string) error {
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w.w, prefix); err != nil {
+		return err
+	}
+	if _, err := w.w.Write(encoded); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w.w, suffix)
+	return err
+}
@@ -0,0 +1,67 @@
+package translit
+
+import (
+	"strings"
+	"unicode"
+)
+
+// cyrillic follows the passport (ICAO) romanization of Russian, with the
+// extra Ukrainian and Belarusian letters.
+var cyrillic = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "i", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "ie", 'ы': "y", 'ь': "", 'э': "e", 'ю': "iu", 'я': "ia",
+	'є': "ie", 'і': "i", 'ї': "i", 'ґ': "g", 'ў': "u",
+}
+
+// Latin transliterates Cyrillic letters to Latin ones, keeping the case of the
+// first letter. Other characters are left untouched.
+func Latin(text string) string {
+	var builder strings.Builder
+
+	for _, r := range text {
+		latin, ok := cyrillic[unicode.ToLower(r)]
+		if !ok {
+			builder.WriteRune(r)
+			continue
+		}
+
+		if unicode.IsUpper(r) && latin != "" {
+			latin = strings.ToUpper(latin[:1]) + latin[1:]
+		}
+		builder.WriteString(latin)
+	}
+
+	return builder.String()
+}
+
+// Filename turns text into a safe ASCII file name: it is transliterated and
+// everything except letters, digits, `.`, `_` and `-` becomes a single `-`.
+// It returns fallback when nothing usable is left.
+func Filename(text string, fallback string) string {
+	var builder strings.Builder
+	dash := false
+
+	for _, r := range Latin(text) {
+		safe := r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '_' || r == '-')
+		if !safe {
+			dash = builder.Len() > 0
+			continue
+		}
+
+		if dash {
+			builder.WriteByte('-')
+			dash = false
+		}
+		builder.WriteRune(r)
+	}
+
+	name := strings.Trim(builder.String(), ".-")
+	if name == "" {
+		return fallback
+	}
+
+	return name
+}
@@ -0,0 +1,50 @@
+package translit
+
+import "testing"
+
+func TestLatin(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "Привет, мир!", want: "Privet, mir!"},
+		{text: "Щука и ёж", want: "Shchuka i ezh"},
+		{text: "Подъезд", want: "Podieezd"},
+		{text: "Мышь", want: "Mysh"},
+		{text: "Їжак ґанок", want: "Izhak ganok"},
+		{text: "plain text", want: "plain text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := Latin(tt.text); got != tt.want {
+				t.Errorf("Latin(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilename(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "cyrillic", text: "Привет, мир!", want: "Privet-mir"},
+		{name: "spaces collapse", text: "a   b\tc", want: "a-b-c"},
+		{name: "safe characters stay", text: "report_v1.2-final", want: "report_v1.2-final"},
+		{name: "edges are trimmed", text: "..hidden.", want: "hidden"},
+		{name: "path separators", text: "../etc/passwd", want: "etc-passwd"},
+		{name: "non-latin letters", text: "日本語", want: "paste"},
+		{name: "emoji between words", text: "cat 🐱 dog", want: "cat-dog"},
+		{name: "empty", text: "", want: "paste"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Filename(tt.text, "paste"); got != tt.want {
+				t.Errorf("Filename(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
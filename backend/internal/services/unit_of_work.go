@@ -0,0 +1,25 @@
+package services
+
+import (
+	"api/internal/repositories"
+	"context"
+)
+
+// within runs fn in a unit of work and returns what fn produced once the
+// transaction is committed.
+func within[T any](ctx context.Context, uow repositories.UnitOfWork, fn func(r *repositories.Repositories) (T, error)) (T, error) {
+	var result T
+
+	err := uow.Do(ctx, func(r *repositories.Repositories) error {
+		var err error
+		result, err = fn(r)
+		return err
+	})
+
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return result, nil
+}
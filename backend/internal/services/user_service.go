@@ -1,177 +1,282 @@
 package services
 
 import (
+	"api/internal/domain"
 	"api/internal/dtos"
+	"api/internal/enums"
+	"api/internal/models"
 	"api/internal/repositories"
-	"api/internal/responses"
-	"api/internal/services/querymap"
+	"api/internal/services/cache"
+	"api/internal/services/patch"
 	"api/internal/services/validators"
-
-	"github.com/gofiber/fiber/v2"
+	"context"
+	"log/slog"
 )
 
 type UserService interface {
-	Find(c *fiber.Ctx) error
-	Create(c *fiber.Ctx) error
-	Update(c *fiber.Ctx) error
-	Delete(c *fiber.Ctx) error
+	Find(ctx context.Context, filter *dtos.UserFiltersDto) (*models.UserModel, error)
+	FindOne(ctx context.Context, target *dtos.UserFiltersDto) (*models.UserModel, error)
+	Create(ctx context.Context, dto *dtos.UserDto) (*models.UserModel, error)
+	Update(ctx context.Context, target *dtos.UserFiltersDto, dto *dtos.UpdateUserDto, precondition Precondition) (*models.UserModel, error)
+	Patch(ctx context.Context, target *dtos.UserFiltersDto, document []byte, contentType string, precondition Precondition) (*models.UserModel, error)
+	Delete(ctx context.Context, target *dtos.UserFiltersDto, precondition Precondition) error
+	Suggest(ctx context.Context, query *dtos.SuggestQueryDto) ([]*models.SuggestionModel, error)
 }
 
 type userService struct {
+	uow            repositories.UnitOfWork
 	userRepository repositories.UserRepository
+	suggestions    *cache.Cache[string, []*models.SuggestionModel]
+	logger         *slog.Logger
 }
 
-func NewUserService(r repositories.UserRepository) UserService {
-	return &userService{userRepository: r}
+func NewUserService(uow repositories.UnitOfWork, r repositories.UserRepository, logger *slog.Logger) UserService {
+	return &tracingUserService{next: &userService{
+		uow:            uow,
+		userRepository: r,
+		suggestions:    cache.New[string, []*models.SuggestionModel](suggestCacheSize, suggestCacheTTL),
+		logger:         logger,
+	}}
 }
 
-func (u *userService) Find(c *fiber.Ctx) error {
-	queryObj, err := querymap.FromURLStringToStruct[dtos.UserFiltersDto](c.BaseURL() + c.OriginalURL())
-	if u.isEmptyQuery(queryObj) {
-		return c.Status(fiber.StatusBadRequest).JSON(responses.NewBadRequestError("Query parametrs is empty"))
-	}
-
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.NewInternalError("Cannot parse query parametrs.."))
-	}
-	filterViolations := validators.AppValidatorInstance.Validate(queryObj)
-	if filterViolations != nil {
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(filterViolations)
-	}
-
-	result, err := u.userRepository.Find(queryObj)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.NewInternalError("Error while query to db"))
+func (u *userService) Find(ctx context.Context, filter *dtos.UserFiltersDto) (*models.UserModel, error) {
+	if u.isEmptyQuery(filter) {
+		return nil, domain.NewInvalidInputError("Query parametrs is empty")
 	}
 
-	if result == nil {
-		return c.Status(fiber.StatusNotFound).JSON(responses.NewNotFoundError("User not found"))
+	if violations := validators.Validate(ctx, filter); violations != nil {
+		return nil, violations
 	}
 
-	return c.Status(fiber.StatusOK).JSON(result)
+	return u.FindOne(ctx, filter)
 }
 
-func (u *userService) Create(c *fiber.Ctx) error {
-	var body *dtos.UserDto
+// FindOne returns the user matching target, which identifies it by id or
+// socialId.
+func (u *userService) FindOne(ctx context.Context, target *dtos.UserFiltersDto) (*models.UserModel, error) {
+	return findUser(ctx, u.userRepository, target)
+}
 
-	if err := c.BodyParser(&body); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.NewInternalError("Cannot parse body..."))
+func (u *userService) Create(ctx context.Context, dto *dtos.UserDto) (*models.UserModel, error) {
+	if violations := validators.Validate(ctx, dto); violations != nil {
+		return nil, violations
 	}
 
-	bodyViolations := validators.AppValidatorInstance.Validate(body)
+	newUsr, err := within(ctx, u.uow, func(r *repositories.Repositories) (*models.UserModel, error) {
+		strict := true
+		existed, err := r.Users.Find(ctx, &dtos.UserFiltersDto{
+			Username: &dto.Username,
+			SocialId: &dto.SocialId,
+			Strict:   &strict,
+		})
 
-	if bodyViolations != nil {
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(bodyViolations)
-	}
+		if err != nil {
+			return nil, err
+		}
+
+		if existed != nil {
+			return nil, domain.NewConflictError("User already exists", "username", "socialId")
+		}
 
-	strict := true
-	existed, err := u.userRepository.Find(&dtos.UserFiltersDto{
-		Username: &body.Username,
-		SocialId: &body.SocialId,
-		Strict:   &strict,
+		newUsr, err := r.Users.Create(ctx, dto)
+		if err != nil {
+			return nil, err
+		}
+
+		return newUsr, r.Audit.Record(ctx, enums.AuditUser, newUsr.Id, enums.AuditCreate, newUsr.Version)
 	})
 
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.NewInternalError("Error while query to db"))
+		return nil, err
 	}
 
-	if existed != nil {
-		return c.
-			Status(fiber.StatusUnprocessableEntity).
-			JSON(responses.NewValidationError("User already exists", []responses.Violation{
-				*responses.NewViolation("User already exists", "username"),
-				*responses.NewViolation("User already exists", "social_id"),
-			}))
+	u.suggestions.Purge()
+	u.logger.InfoContext(ctx, "User created", "id", newUsr.Id)
+
+	return newUsr, nil
+}
+
+func (u *userService) Update(ctx context.Context, target *dtos.UserFiltersDto, dto *dtos.UpdateUserDto, precondition Precondition) (*models.UserModel, error) {
+	if violations := validators.Validate(ctx, dto); violations != nil {
+		return nil, violations
 	}
 
-	newUsr, err := u.userRepository.Create(body)
+	return u.write(ctx, func(r *repositories.Repositories) (*models.UserModel, error) {
+		existed, err := findUser(ctx, r.Users, target)
+		if err != nil {
+			return nil, err
+		}
+
+		version, ok := precondition(existed.Version)
+		if !ok {
+			return nil, domain.NewPreconditionFailedError("User was modified by someone else")
+		}
+
+		newUsr, err := r.Users.Update(ctx, target, dto, version)
+		if err != nil {
+			return nil, err
+		}
 
-	return c.Status(fiber.StatusOK).JSON(newUsr)
+		if newUsr, err = written(newUsr, version); err != nil {
+			return nil, err
+		}
+
+		return newUsr, r.Audit.Record(ctx, enums.AuditUser, newUsr.Id, enums.AuditUpdate, newUsr.Version)
+	})
 }
 
-func (u *userService) Update(c *fiber.Ctx) error {
-	var body dtos.UpdateUserDto
+// Patch applies a merge patch (RFC 7396) or a JSON Patch (RFC 6902) to the
+// user and writes only the columns that actually changed.
+func (u *userService) Patch(ctx context.Context, target *dtos.UserFiltersDto, document []byte, contentType string, precondition Precondition) (*models.UserModel, error) {
+	return u.write(ctx, func(r *repositories.Repositories) (*models.UserModel, error) {
+		existed, err := findUser(ctx, r.Users, target)
+		if err != nil {
+			return nil, err
+		}
+
+		version, ok := precondition(existed.Version)
+		if !ok {
+			return nil, domain.NewPreconditionFailedError("User was modified by someone else")
+		}
+
+		original := &dtos.UpdateUserDto{Username: existed.Username, DisplayName: existed.DisplayName}
+		patched, err := patch.Apply(original, document, contentType)
+		if err != nil {
+			return nil, err
+		}
+
+		if violations := validators.Validate(ctx, patched); violations != nil {
+			return nil, violations
+		}
+
+		var changes dtos.PatchUserDto
+
+		if patched.Username != original.Username {
+			changes.Username = &patched.Username
+		}
+
+		if patched.DisplayName != original.DisplayName {
+			changes.DisplayName = &patched.DisplayName
+		}
+
+		newUsr, err := r.Users.Patch(ctx, target, &changes, version)
+		if err != nil {
+			return nil, err
+		}
+
+		if newUsr, err = written(newUsr, version); err != nil {
+			return nil, err
+		}
+
+		// A patch that changes nothing does not bump the version.
+		if newUsr.Version == existed.Version {
+			return newUsr, nil
+		}
+
+		return newUsr, r.Audit.Record(ctx, enums.AuditUser, newUsr.Id, enums.AuditUpdate, newUsr.Version)
+	})
+}
 
-	if err := c.BodyParser(&body); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.NewInternalError("Cannot parse body..."))
-	}
+func (u *userService) Delete(ctx context.Context, target *dtos.UserFiltersDto, precondition Precondition) error {
+	var id int
 
-	queryObj, err := querymap.FromURLStringToStruct[dtos.UserFiltersDto](c.BaseURL() + c.OriginalURL())
-	if u.isEmptyQuery(queryObj) {
-		return c.Status(fiber.StatusBadRequest).JSON(responses.NewBadRequestError("Query parametrs is empty"))
-	}
+	err := u.uow.Do(ctx, func(r *repositories.Repositories) error {
+		existed, err := findUser(ctx, r.Users, target)
+		if err != nil {
+			return err
+		}
 
-	filterViolations := validators.AppValidatorInstance.Validate(queryObj)
-	bodyViolations := validators.AppValidatorInstance.Validate(body)
+		version, ok := precondition(existed.Version)
+		if !ok {
+			return domain.NewPreconditionFailedError("User was modified by someone else")
+		}
 
-	if filterViolations != nil {
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(filterViolations)
-	}
+		deleted, err := r.Users.Delete(ctx, target, version)
+		if err != nil {
+			return err
+		}
 
-	if bodyViolations != nil {
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(bodyViolations)
-	}
+		if !deleted && version != nil {
+			return domain.NewPreconditionFailedError("User was modified by someone else")
+		}
 
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.NewInternalError("Cannot parse query parametrs.."))
-	}
+		if !deleted {
+			return domain.NewNotFoundError("User not found")
+		}
 
-	result, err := u.userRepository.Find(queryObj)
+		id = existed.Id
+		return r.Audit.Record(ctx, enums.AuditUser, existed.Id, enums.AuditDelete, existed.Version)
+	})
 
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.NewInternalError("Error while query to db"))
+		return err
 	}
 
-	if result == nil {
-		return c.Status(fiber.StatusNotFound).JSON(responses.NewNotFoundError("User not found"))
+	u.suggestions.Purge()
+	u.logger.InfoContext(ctx, "User deleted", "id", id)
+
+	return nil
+}
+
+// Suggest matches the query against usernames and display names, by prefix or similarity.
+func (u *userService) Suggest(ctx context.Context, query *dtos.SuggestQueryDto) ([]*models.SuggestionModel, error) {
+	if violations := validators.Validate(ctx, query); violations != nil {
+		return nil, violations
 	}
 
-	newUsr, err := u.userRepository.Update(queryObj, &body)
+	key := suggestCacheKey(query)
+	if suggestions, ok := u.suggestions.Get(key); ok {
+		return suggestions, nil
+	}
 
+	suggestions, err := u.userRepository.Suggest(ctx, query, suggestLimit(query))
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.NewInternalError("Error while query to db"))
+		return nil, err
 	}
 
-	if newUsr == nil {
-		return c.Status(fiber.StatusNotFound).JSON(responses.NewNotFoundError("User not found"))
-	}
+	u.suggestions.Set(key, suggestions)
 
-	return c.Status(fiber.StatusOK).JSON(newUsr)
+	return suggestions, nil
 }
 
-func (u *userService) Delete(c *fiber.Ctx) error {
-	queryObj, err := querymap.FromURLStringToStruct[dtos.UserFiltersDto](c.BaseURL() + c.OriginalURL())
-	if u.isEmptyQuery(queryObj) {
-		return c.Status(fiber.StatusBadRequest).JSON(responses.NewBadRequestError("Query parametrs is empty"))
-	}
+// write runs an update of an existing user in a unit of work.
+func (u *userService) write(ctx context.Context, fn func(r *repositories.Repositories) (*models.UserModel, error)) (*models.UserModel, error) {
+	newUsr, err := within(ctx, u.uow, fn)
 
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.NewInternalError("Cannot parse query parametrs.."))
+		return nil, err
 	}
 
-	filterViolations := validators.AppValidatorInstance.Validate(queryObj)
-
-	if filterViolations != nil {
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(filterViolations)
-	}
+	u.suggestions.Purge()
+	u.logger.InfoContext(ctx, "User updated", "id", newUsr.Id, "version", newUsr.Version)
 
-	existed, err := u.userRepository.Find(queryObj)
+	return newUsr, nil
+}
 
-	if existed == nil {
-		return c.Status(fiber.StatusNotFound).JSON(responses.NewNotFoundError("User not found"))
+func findUser(ctx context.Context, r repositories.UserRepository, target *dtos.UserFiltersDto) (*models.UserModel, error) {
+	result, err := r.Find(ctx, target)
+	if err != nil {
+		return nil, err
 	}
 
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.NewInternalError("Error while quering existed user"))
+	if result == nil {
+		return nil, domain.NewNotFoundError("User not found")
 	}
 
-	_, err = u.userRepository.Delete(queryObj)
+	return result, nil
+}
 
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.NewInternalError("Error while deleting user"))
+// written tells why a conditional write found no row, if it did not.
+func written(newUsr *models.UserModel, version *int) (*models.UserModel, error) {
+	if newUsr == nil && version != nil {
+		return nil, domain.NewPreconditionFailedError("User was modified by someone else")
+	}
+
+	if newUsr == nil {
+		return nil, domain.NewNotFoundError("User not found")
 	}
 
-	return c.SendStatus(fiber.StatusNoContent)
+	return newUsr, nil
 }
 
 func (u *userService) isEmptyQuery(q *dtos.UserFiltersDto) bool {
@@ -1,7 +1,9 @@
 package validators
 
 import (
-	"api/internal/responses"
+	"api/internal/domain"
+	"api/internal/tracing"
+	"context"
 	"reflect"
 	"strings"
 
@@ -9,7 +11,7 @@ import (
 )
 
 type AppValidator interface {
-	Validate(body any) *responses.ValidationError
+	Validate(body any) *domain.ValidationError
 }
 
 type validationFormatter struct {
@@ -22,19 +24,19 @@ func NewAppValidator() AppValidator {
 	}
 }
 
-func (f *validationFormatter) Validate(body any) *responses.ValidationError {
+func (f *validationFormatter) Validate(body any) *domain.ValidationError {
 	err := f.validate.Struct(body)
-	var violations []responses.Violation
+	var violations []domain.Violation
 
 	if err == nil {
 		return nil
 	}
 
 	for _, violation := range err.(validator.ValidationErrors) {
-		violations = append(violations, *responses.NewViolation(f.formatErrorMessage(violation), f.getJSONFieldName(violation, body)))
+		violations = append(violations, domain.NewViolation(f.formatErrorMessage(violation), f.getJSONFieldName(violation, body)))
 	}
 
-	return responses.NewValidationError("Invalid payload", violations)
+	return domain.NewValidationError("Invalid payload", violations...)
 }
 
 func (f *validationFormatter) getJSONFieldName(fieldErr validator.FieldError, body any) string {
@@ -62,3 +64,17 @@ func (f *validationFormatter) formatErrorMessage(fieldErr validator.FieldError)
 }
 
 var AppValidatorInstance AppValidator = NewAppValidator()
+
+// Validate validates body with AppValidatorInstance in a span named validate.
+func Validate(ctx context.Context, body any) *domain.ValidationError {
+	_, span := tracing.Start(ctx, "validate")
+
+	violations := AppValidatorInstance.Validate(body)
+	if violations != nil {
+		tracing.End(span, violations)
+	} else {
+		tracing.End(span, nil)
+	}
+
+	return violations
+}
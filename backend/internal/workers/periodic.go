@@ -0,0 +1,85 @@
+package workers
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Periodic runs a job every interval in the background until it is stopped.
+type Periodic struct {
+	name     string
+	interval time.Duration
+	job      func(ctx context.Context) error
+	logger   *slog.Logger
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+func NewPeriodic(name string, interval time.Duration, job func(ctx context.Context) error, logger *slog.Logger) *Periodic {
+	return &Periodic{name: name, interval: interval, job: job, logger: logger.With("worker", name)}
+}
+
+func (p *Periodic) Name() string {
+	return p.name
+}
+
+// Start launches the worker. The first run happens after one interval.
+func (p *Periodic) Start(_ context.Context) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done = make(chan struct{})
+
+	go p.loop(ctx)
+
+	return nil
+}
+
+// Stop cancels the running job and waits for it to return, or for ctx to
+// expire.
+func (p *Periodic) Stop(ctx context.Context) error {
+	if p.cancel == nil {
+		return nil
+	}
+
+	p.cancel()
+
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Running tells whether the worker was started and has not stopped since.
+func (p *Periodic) Running() bool {
+	if p.done == nil {
+		return false
+	}
+
+	select {
+	case <-p.done:
+		return false
+	default:
+		return true
+	}
+}
+
+func (p *Periodic) loop(ctx context.Context) {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.job(ctx); err != nil && ctx.Err() == nil {
+				p.logger.ErrorContext(ctx, "Worker failed", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    entity VARCHAR(32) NOT NULL,
+    entity_id INT NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    version INT NOT NULL,
+    created_at TIMESTAMP DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log (entity, entity_id);
+
+CREATE TABLE IF NOT EXISTS paste_revisions (
+    id SERIAL PRIMARY KEY,
+    paste_id INT NOT NULL,
+    version INT NOT NULL,
+    title VARCHAR(32) NOT NULL,
+    paste TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT now(),
+    CONSTRAINT fk_paste_revisions_paste
+        FOREIGN KEY (paste_id)
+        REFERENCES pastes(id)
+        ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_paste_revisions_paste_version ON paste_revisions (paste_id, version);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_paste_revisions_paste_version;
+DROP TABLE IF EXISTS paste_revisions;
+DROP INDEX IF EXISTS idx_audit_log_entity;
+DROP TABLE IF EXISTS audit_log;
+-- +goose StatementEnd
@@ -27,17 +27,14 @@ export class PastesApi extends BaseApi {
     });
   }
 
-  async updatePaste(f: Partial<PasteFilter>, p: UpdatePastePayload) {
-    return await rest.put<Paste, UpdatePastePayload>(
-      "/pastes" + this.getQuery(f),
-      {
-        body: p,
-      }
-    );
+  async updatePaste(id: number, p: UpdatePastePayload) {
+    return await rest.put<Paste, UpdatePastePayload>(`/pastes/${id}`, {
+      body: p,
+    });
   }
 
-  async deletePaste(f: Partial<PasteFilter>) {
-    return await rest.delete("/pastes" + this.getQuery(f));
+  async deletePaste(id: number) {
+    return await rest.delete(`/pastes/${id}`);
   }
 }
 
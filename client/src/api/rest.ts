@@ -5,6 +5,7 @@ import type {
   ResponseInterceptor,
 } from "@ts-fetcher/types";
 import { Logger } from "ayologger";
+import { randomBytes } from "crypto";
 
 import { Env } from "#config/env.js";
 
@@ -48,6 +49,18 @@ const logger = new Logger({
   },
 });
 
+// Every request starts a trace of its own, which the backend continues
+// (W3C Trace Context)
+const TraceRequestInterceptor: RequestInterceptor = (options) => {
+  const traceId = randomBytes(16).toString("hex");
+  const spanId = randomBytes(8).toString("hex");
+  options.headers = {
+    ...options.headers,
+    traceparent: `00-${traceId}-${spanId}-01`,
+  };
+  return options;
+};
+
 const LogRequestInterceptor: RequestInterceptor = (options) => {
   logger.info(`${options.method} | ${options.path} | incoming request`);
   return options;
@@ -74,7 +87,7 @@ export const rest = createRestInstance(Env.ApiUrl, {
   },
   caching: new LocalCache(),
   interceptors: {
-    request: [LogRequestInterceptor],
+    request: [TraceRequestInterceptor, LogRequestInterceptor],
     response: [LogResponseInterceptor],
   },
 });
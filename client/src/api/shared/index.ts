@@ -40,3 +40,39 @@ export interface ListResponse<T> {
   items: T[];
   hasNext: boolean;
 }
+
+export const ProblemCode = {
+  BadRequest: "bad_request",
+  Unauthorized: "unauthorized",
+  Forbidden: "forbidden",
+  NotFound: "not_found",
+  MethodNotAllowed: "method_not_allowed",
+  Conflict: "conflict",
+  PreconditionFailed: "precondition_failed",
+  PayloadTooLarge: "payload_too_large",
+  UnsupportedMediaType: "unsupported_media_type",
+  ValidationFailed: "validation_failed",
+  AlreadyExists: "already_exists",
+  FailedDependency: "failed_dependency",
+  TooManyRequests: "too_many_requests",
+  Internal: "internal",
+  Unavailable: "unavailable",
+  Timeout: "timeout",
+} as const;
+
+export type ProblemCode = LiteralEnum<typeof ProblemCode>;
+
+export interface Violation {
+  message: string;
+  propertyPath: string;
+}
+
+export interface Problem {
+  type: string;
+  title: string;
+  status: number;
+  detail?: string;
+  instance?: string;
+  code: ProblemCode;
+  violations?: Violation[];
+}
@@ -40,17 +40,14 @@ export class UsersApi extends BaseApi {
     });
   }
 
-  async updateUser(f: Partial<UserFilter>, p: UpdateUserPayload) {
-    return await rest.put<User, UpdateUserPayload>(
-      "/users" + this.getQuery(f),
-      {
-        body: p,
-      }
-    );
+  async updateUser(id: number, p: UpdateUserPayload) {
+    return await rest.put<User, UpdateUserPayload>(`/users/${id}`, {
+      body: p,
+    });
   }
 
-  async deleteUser(f: Partial<UserFilter>) {
-    return await rest.delete("/users" + this.getQuery(f));
+  async deleteUser(id: number) {
+    return await rest.delete(`/users/${id}`);
   }
 }
 
@@ -280,7 +280,7 @@ export class PasteService {
       strict: true,
     });
 
-    if (newExisted.success) {
+    if (newExisted.success && newExisted.data.id !== numPasteId) {
       return interaction.editReply({
         embeds: [
           embed
@@ -290,15 +290,10 @@ export class PasteService {
       });
     }
 
-    const updated = await pastesApi.updatePaste(
-      {
-        pasteId: existed.data.id,
-      },
-      {
-        title,
-        paste,
-      }
-    );
+    const updated = await pastesApi.updatePaste(existed.data.id, {
+      title,
+      paste,
+    });
 
     if (!updated.success) {
       return interaction.editReply({
@@ -349,9 +344,7 @@ export class PasteService {
       });
     }
 
-    const deleted = await pastesApi.deletePaste({
-      pasteId: Number(pasteId),
-    });
+    const deleted = await pastesApi.deletePaste(numPasteId);
 
     if (!deleted || !deleted.success) {
       return interaction.editReply({